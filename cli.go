@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	goio "io"
+	nethttp "net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"pet-spotlight/description"
+	"pet-spotlight/dog"
+	"pet-spotlight/export"
+	"pet-spotlight/http"
+	"pet-spotlight/io"
+	"pet-spotlight/match"
+	"pet-spotlight/metadata"
+	"pet-spotlight/organization"
+	"pet-spotlight/photo"
+	"pet-spotlight/profile"
+	"pet-spotlight/progress"
+	"pet-spotlight/social"
+	"pet-spotlight/source"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	fostersCommand  = "fosters"
+	downloadCommand = "download"
+	collageCommand  = "collage"
+	helpCommand     = "help"
+)
+
+// Exit codes returned by the command-line mode.
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitPartial
+)
+
+const usage = `Usage: pet-spotlight-ui <command> [options]
+
+Commands:
+  fosters   print the dogs that need a foster
+  download  download the descriptions, images and videos of the dogs
+  collage   create a collage of the images of a downloaded dog
+
+Options:
+  --dogs     (download) the comma separated list of dogs to download. Required
+  --out      (download) the directory to save the dogs to. Defaults to the current directory
+  --threshold
+             (download) the lowest confidence from 0 to 1 a listing is matched to a dog at. Defaults to 0.75
+  --strict   (download) fail without downloading anything when a name matches more than one dog. Without it, the
+             dog to download is asked for when running in a terminal
+  --refresh  (download) only download the images and videos that are new or changed since the last download
+  --image-format
+             (download) the format to convert images to (jpeg, png or gif). Defaults to the original format
+  --presets  (download) the comma separated social media sizes to create copies of the images in, or "all". The sizes
+             are loaded from presets.json, falling back to the built-in sizes
+  --watermark
+             (download) the JSON file of the watermark to create watermarked copies of the images with
+  --posts    (download) the comma separated social media platforms to compose posts for, or "all". The platforms are
+             loaded from platforms.json, falling back to twitter, instagram and facebook
+  --template (download) the name of the description template, e.g. adoption, foster-needed or urgent. Defaults to
+             adoption
+  --templates
+             (download) the directory of the .tmpl description templates. Defaults to templates, falling back to the
+             built-in templates
+  --collage  (download, collage) the grid of the collage as columns x rows (e.g. 2x2 or 3x1). The collage is loaded
+             from collage.json, falling back to the built-in collage
+  --dir      (collage) the folder of the downloaded dog. Required
+  --export   (fosters) the .csv, .json or .xlsx file to export the fosters to
+  --org      the ID or name of the organization. Defaults to all organizations when looking up fosters and the first
+             organization when downloading
+  --orgs     the JSON file of the organizations. Defaults to organizations.json
+  --profile  the JSON file of the selectors used to scrape the pages. Defaults to profile.json, falling back to the
+             built-in selectors
+  --attempts the most times a page, image or video is requested before giving up. Failed requests are retried with
+             a growing delay. Defaults to 3
+  --client   the JSON file of the timeouts, proxy, certificate authorities and User-Agent of the requests. Defaults to
+             client.json, falling back to the built-in settings
+  --robots   skip the pages, images and videos the robots.txt of their site does not allow. Also enabled by
+             "robotsTxt" in the client settings
+  --offline  only use the pages cached by earlier runs, without requesting anything. Also enabled by "offline" in
+             the cache of the client settings
+
+Running without a command starts the UI.`
+
+var errNoCommand = errors.New("no command provided")
+
+// parseFlags parses the command-line arguments (excluding the program name) into flags.
+func parseFlags(args []string) (flags, error) {
+	var f flags
+	if len(args) == 0 {
+		return f, errNoCommand
+	}
+	switch args[0] {
+	case fostersCommand:
+		fs := flag.NewFlagSet(fostersCommand, flag.ContinueOnError)
+		fs.StringVar(&f.exportFile, "export", "", "file to export the fosters to (.csv, .json or .xlsx)")
+		addOrganizationFlags(fs, &f)
+		addRequestFlags(fs, &f)
+		if err := fs.Parse(args[1:]); err != nil {
+			return f, err
+		}
+		if err := checkRequestFlags(f); err != nil {
+			return f, err
+		}
+		if len(f.exportFile) > 0 {
+			if _, err := export.FormatOf(f.exportFile); err != nil {
+				return f, err
+			}
+		}
+		f.determineFosters = true
+	case downloadCommand:
+		dir, err := os.Getwd()
+		if err != nil {
+			return f, err
+		}
+		fs := flag.NewFlagSet(downloadCommand, flag.ContinueOnError)
+		fs.StringVar(&f.dogs, "dogs", "", "comma separated list of dogs to download")
+		fs.StringVar(&f.baseDirectory, "out", dir, "directory to save the dogs to")
+		fs.Float64Var(&f.matchThreshold, "threshold", match.DefaultThreshold, "lowest confidence a listing is matched at")
+		fs.BoolVar(&f.strict, "strict", false, "fail when a name matches more than one dog")
+		fs.BoolVar(&f.refresh, "refresh", false, "only download new or changed files")
+		fs.StringVar(&f.imageFormat, "image-format", "", "format to convert images to (jpeg, png or gif)")
+		fs.StringVar(&f.presets, "presets", "", "comma separated social media sizes to create, or all")
+		fs.StringVar(&f.watermarkFile, "watermark", "", "JSON file of the watermark to create watermarked copies with")
+		fs.StringVar(&f.collage, "collage", "", "grid of the collage to create as columns x rows")
+		fs.StringVar(&f.platforms, "posts", "", "comma separated social media platforms to compose posts for, or all")
+		fs.StringVar(&f.template, "template", description.Adoption, "name of the description template")
+		fs.StringVar(&f.templatesDirectory, "templates", description.DefaultDirectory, "directory of the description templates")
+		addOrganizationFlags(fs, &f)
+		addRequestFlags(fs, &f)
+		if err := fs.Parse(args[1:]); err != nil {
+			return f, err
+		}
+		if err := checkRequestFlags(f); err != nil {
+			return f, err
+		}
+		if len(f.dogs) == 0 {
+			return f, errors.New("--dogs is required")
+		}
+		if f.matchThreshold <= 0 || f.matchThreshold > 1 {
+			return f, errors.New("--threshold must be greater than 0 and at most 1")
+		}
+		imageFormat, err := http.ParseImageFormat(f.imageFormat)
+		if err != nil {
+			return f, err
+		}
+		f.imageFormat = imageFormat
+	case collageCommand:
+		fs := flag.NewFlagSet(collageCommand, flag.ContinueOnError)
+		fs.StringVar(&f.collageDirectory, "dir", "", "folder of the downloaded dog")
+		fs.StringVar(&f.collage, "collage", "", "grid of the collage as columns x rows")
+		if err := fs.Parse(args[1:]); err != nil {
+			return f, err
+		}
+		if len(f.collageDirectory) == 0 {
+			return f, errors.New("--dir is required")
+		}
+	case helpCommand, "-h", "-help", "--help":
+		return f, flag.ErrHelp
+	default:
+		return f, fmt.Errorf("unknown command %q", args[0])
+	}
+	return f, nil
+}
+
+func addOrganizationFlags(fs *flag.FlagSet, f *flags) {
+	fs.StringVar(&f.organization, "org", "", "ID or name of the organization")
+	fs.StringVar(&f.organizationsFile, "orgs", organization.DefaultFile, "JSON file of the organizations")
+	fs.StringVar(&f.profileFile, "profile", profile.DefaultFile, "JSON file of the scrape selectors")
+}
+
+func addRequestFlags(fs *flag.FlagSet, f *flags) {
+	fs.IntVar(&f.attempts, "attempts", http.DefaultAttempts, "most times a request is made before giving up")
+	fs.StringVar(&f.clientFile, "client", http.DefaultClientFile, "JSON file of the client settings")
+	fs.BoolVar(&f.robots, "robots", false, "skip the pages and files robots.txt does not allow")
+	fs.BoolVar(&f.offline, "offline", false, "only use the cached pages")
+}
+
+func checkRequestFlags(f flags) error {
+	if f.attempts < 1 {
+		return errors.New("--attempts must be at least 1")
+	}
+	return nil
+}
+
+// newClient creates the client that makes all the requests of the command with the client settings, retrying failed
+// requests.
+func newClient(f flags) (*nethttp.Client, error) {
+	config, err := http.LoadClientConfigOrDefault(f.clientFile)
+	if err != nil {
+		return nil, err
+	}
+	policy := http.DefaultRetryPolicy
+	policy.Attempts = f.attempts
+	config.RobotsTxt = config.RobotsTxt || f.robots
+	config.Cache.Offline = config.Cache.Offline || f.offline
+	return http.NewClient(config, policy)
+}
+
+// runCLI runs the command-line mode without starting the UI and returns the exit code of the process.
+func runCLI(args []string) int {
+	f, err := parseFlags(args)
+	if err == flag.ErrHelp {
+		fmt.Println(usage)
+		return exitOK
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usage)
+		return exitUsage
+	}
+	if len(f.collageDirectory) > 0 {
+		if err = runCollage(f.collageDirectory, f.collage); err != nil {
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	// Stop the requests when interrupted, so no partial files are left behind
+	ctx, stop := interruptContext()
+	defer stop()
+	// Print the errors as they come in and keep track if any occurred
+	errorChannel := make(chan error, 10)
+	var errorCount int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range errorChannel {
+			errorCount++
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
+		}
+	}()
+	orgs, runErr := selectOrganizations(f)
+	var p profile.Profile
+	if runErr == nil {
+		p, runErr = profile.LoadOrDefault(f.profileFile)
+	}
+	var client *nethttp.Client
+	if runErr == nil {
+		client, runErr = newClient(f)
+	}
+	if runErr == nil {
+		if f.determineFosters {
+			runErr = runFosters(ctx, orgs, p, client, f.exportFile, errorChannel)
+		} else {
+			runErr = runDownload(ctx, orgs[0], p, client, f, errorChannel)
+		}
+	}
+	close(errorChannel)
+	wg.Wait()
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", runErr)
+		return exitFailure
+	}
+	if errorCount > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+// selectOrganizations loads the configured organizations and narrows them down to the requested organization.
+func selectOrganizations(f flags) ([]organization.Organization, error) {
+	orgs, err := organization.LoadOrDefault(f.organizationsFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.organization) == 0 {
+		return orgs, nil
+	}
+	org, ok := organization.Find(orgs, f.organization)
+	if !ok {
+		return nil, fmt.Errorf("organization %q is not configured in %s", f.organization, f.organizationsFile)
+	}
+	return []organization.Organization{org}, nil
+}
+
+// interruptContext returns a context that is canceled when the process is interrupted. The returned function stops
+// listening for the interrupt.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "Canceling...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+func runFosters(ctx context.Context, orgs []organization.Organization, p profile.Profile, client *nethttp.Client, exportFile string, errorChannel chan error) error {
+	fosters, err := RunGetBoardingList(ctx, orgs, p, client, errorChannel)
+	if err != nil {
+		return err
+	}
+	if len(exportFile) > 0 {
+		if err = export.ToFile(exportFile, fosters); err != nil {
+			return err
+		}
+	}
+	for _, foster := range fosters {
+		fmt.Printf("%s\t%s\n", foster.Name, foster.Organization)
+	}
+	return nil
+}
+
+func runDownload(ctx context.Context, org organization.Organization, p profile.Profile, client *nethttp.Client, f flags, errorChannel chan error) error {
+	if err := io.MakeDir(f.baseDirectory); err != nil {
+		return err
+	}
+	opts := DownloadOptions{Refresh: f.refresh, ImageFormat: f.imageFormat, MatchThreshold: f.matchThreshold, Client: client}
+	if !f.strict && isTerminal(os.Stdin) {
+		opts.Disambiguate = promptDisambiguator(os.Stdin, os.Stdout)
+	}
+	templates, err := description.LoadOrDefault(f.templatesDirectory)
+	if err != nil {
+		return err
+	}
+	if opts.Template, err = templates.Get(f.template); err != nil {
+		return err
+	}
+	if len(f.presets) > 0 {
+		presets, err := photo.LoadPresetsOrDefault(photo.DefaultPresetsFile)
+		if err != nil {
+			return err
+		}
+		if opts.Presets, err = photo.SelectPresets(presets, f.presets); err != nil {
+			return err
+		}
+	}
+	if len(f.watermarkFile) > 0 {
+		watermark, err := photo.LoadWatermark(f.watermarkFile)
+		if err != nil {
+			return err
+		}
+		opts.Watermark = &watermark
+	}
+	if len(f.platforms) > 0 {
+		platforms, err := social.LoadPlatformsOrDefault(social.DefaultPlatformsFile)
+		if err != nil {
+			return err
+		}
+		if opts.Platforms, err = social.SelectPlatforms(platforms, f.platforms); err != nil {
+			return err
+		}
+	}
+	if len(f.collage) > 0 {
+		collage, err := loadCollage(f.collage)
+		if err != nil {
+			return err
+		}
+		opts.Collage = &collage
+	}
+	src, err := source.New(org, p, client)
+	if err != nil {
+		return err
+	}
+	progressChannel := make(chan progress.Event, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range progressChannel {
+			if len(event.Message) > 0 {
+				fmt.Println(event)
+			}
+		}
+	}()
+	matches, err := RunDogDownloads(ctx, src, f.dogs, f.baseDirectory, opts, progressChannel, errorChannel)
+	<-done
+	// The download only failed when none of the dogs were found
+	if errors.Is(err, ErrMissing) && len(matches) > 0 {
+		errorChannel <- err
+		return nil
+	}
+	return err
+}
+
+// runCollage creates the collage of the downloaded dog in the directory. The caption is the name of the dog from its
+// metadata, falling back to the name of the directory.
+func runCollage(directory string, template string) error {
+	collage, err := loadCollage(template)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(directory)
+	if m, err := metadata.Read(directory); err == nil && len(m.Name) > 0 {
+		name = m.Name
+	}
+	file, err := photo.WriteCollage(directory, collage, name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(file)
+	return nil
+}
+
+// loadCollage loads the configured collage and replaces its grid with the template, if provided.
+func loadCollage(template string) (photo.Collage, error) {
+	collage, err := photo.LoadCollageOrDefault(photo.DefaultCollageFile)
+	if err != nil {
+		return photo.Collage{}, err
+	}
+	if len(template) > 0 {
+		collage.Template = template
+		if _, _, err = collage.Grid(); err != nil {
+			return photo.Collage{}, err
+		}
+	}
+	return collage, nil
+}
+
+// isTerminal determines if the file is an interactive terminal rather than a pipe or a file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptDisambiguator asks which of the listings to download when a dog matches more than one listing.
+func promptDisambiguator(in goio.Reader, out goio.Writer) Disambiguator {
+	reader := bufio.NewReader(in)
+	return func(query string, candidates []dog.Dog) (int, error) {
+		fmt.Fprintf(out, "'%s' matches more than one dog:\n", query)
+		for i, candidate := range candidates {
+			fmt.Fprintf(out, "  %d) %s %s\n", i+1, candidate.Name, candidate.URL)
+		}
+		for {
+			fmt.Fprintf(out, "Which dog should be downloaded (1-%d, or 0 to skip)? ", len(candidates))
+			line, err := reader.ReadString('\n')
+			if choice, convErr := strconv.Atoi(strings.TrimSpace(line)); convErr == nil && choice >= 0 && choice <= len(candidates) {
+				return choice - 1, nil
+			}
+			if err != nil {
+				return 0, fmt.Errorf("failed to read the dog to download for '%s': %w", query, err)
+			}
+		}
+	}
+}
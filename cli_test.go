@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	"strings"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	f, err := parseFlags([]string{"fosters"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.determineFosters {
+		t.Error("expected fosters to be determined")
+	}
+	f, err = parseFlags([]string{"download", "--dogs", "bella,max", "--out", "/tmp/dogs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.determineFosters {
+		t.Error("expected fosters to not be determined")
+	}
+	if f.dogs != "bella,max" {
+		t.Errorf("dogs is %s", f.dogs)
+	}
+	if f.baseDirectory != "/tmp/dogs" {
+		t.Errorf("base directory is %s", f.baseDirectory)
+	}
+	if f.attempts != 3 {
+		t.Errorf("attempts is %d", f.attempts)
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	if _, err := parseFlags(nil); err != errNoCommand {
+		t.Errorf("expected no command error, got %v", err)
+	}
+	if _, err := parseFlags([]string{"help"}); err != flag.ErrHelp {
+		t.Errorf("expected help error, got %v", err)
+	}
+	if _, err := parseFlags([]string{"download"}); err == nil {
+		t.Error("expected error when dogs are missing")
+	}
+	if _, err := parseFlags([]string{"fosters", "--export", "fosters.txt"}); err == nil {
+		t.Error("expected error for unsupported export format")
+	}
+	if _, err := parseFlags([]string{"download", "--dogs", "bella", "--threshold", "2"}); err == nil {
+		t.Error("expected error for threshold over 1")
+	}
+	if _, err := parseFlags([]string{"fosters", "--attempts", "0"}); err == nil {
+		t.Error("expected error for no attempts")
+	}
+	if _, err := parseFlags([]string{"collage"}); err == nil {
+		t.Error("expected error when the directory is missing")
+	}
+	if _, err := parseFlags([]string{"adopt"}); err == nil {
+		t.Error("expected error for unknown command")
+	}
+}
+
+func TestRunCLIUsage(t *testing.T) {
+	if code := runCLI([]string{"adopt"}); code != exitUsage {
+		t.Errorf("exit code is %d", code)
+	}
+	if code := runCLI([]string{"help"}); code != exitOK {
+		t.Errorf("exit code is %d", code)
+	}
+}
+
+func TestRunCLIMissingDogs(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	orgsFile := filepath.Join(dir, "organizations.json")
+	orgs := `[{"id": "1", "name": "Fixture Rescue", "baseURL": "` + server.URL + `"}]`
+	if err = ioutil.WriteFile(orgsFile, []byte(orgs), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is cached and the fixture server is not limited
+	clientFile := filepath.Join(dir, "client.json")
+	if err = ioutil.WriteFile(clientFile, []byte(`{"limits": [], "cache": {"directory": ""}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"download", "--orgs", orgsFile, "--client", clientFile, "--strict", "--out", filepath.Join(dir, "dogs")}
+	if code := runCLI(append(args, "--dogs", "bella,nosuchdog")); code != exitPartial {
+		t.Errorf("exit code is %d when a dog is missing", code)
+	}
+	if code := runCLI(append(args, "--dogs", "nosuchdog")); code != exitFailure {
+		t.Errorf("exit code is %d when every dog is missing", code)
+	}
+}
+
+func TestPromptDisambiguator(t *testing.T) {
+	candidates := []dog.Dog{{Name: "Maxine"}, {Name: "Max"}}
+	var out bytes.Buffer
+	disambiguate := promptDisambiguator(strings.NewReader("three\n2\n0\n"), &out)
+	index, err := disambiguate("max", candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 1 {
+		t.Errorf("picked %d", index)
+	}
+	if index, err = disambiguate("max", candidates); err != nil || index != -1 {
+		t.Errorf("picked %d with error %v", index, err)
+	}
+	if _, err = disambiguate("max", candidates); err == nil {
+		t.Error("expected error when there is no more input")
+	}
+}
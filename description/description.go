@@ -0,0 +1,158 @@
+package description
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultDirectory is the directory the templates are loaded from when no directory is specified.
+const DefaultDirectory = "templates"
+
+// Extension is the extension of the template files.
+const Extension = ".tmpl"
+
+// Names of the built-in templates.
+const (
+	Adoption     = "adoption"
+	FosterNeeded = "foster-needed"
+	Urgent       = "urgent"
+)
+
+// Defaults are the built-in templates by name.
+var Defaults = map[string]string{
+	Adoption: `{{.Description}}
+👇👇SUBMIT AN APPLICATION HERE: 👇👇
+{{.AdoptionURL}}`,
+	FosterNeeded: `🏠 {{.Name}} NEEDS A FOSTER 🏠
+{{.Description}}
+Can you open your home to {{.Name}}? Apply to foster here:
+{{.AdoptionURL}}`,
+	Urgent: `🚨 URGENT - {{.Name}} NEEDS A HOME 🚨
+{{.Description}}
+Please share! Apply here before it's too late:
+{{.AdoptionURL}}
+Posted {{.Date.Format "January 2, 2006"}}`,
+}
+
+// Data is what the templates have access to.
+type Data struct {
+	// Name of the dog.
+	Name string
+	// Description of the dog with the adoption fee part removed.
+	Description string
+	// URL of the listing of the dog.
+	URL string
+	// AdoptionURL is the link for adopting from the organization of the dog.
+	AdoptionURL string
+	// Date the description was written.
+	Date time.Time
+	// Dog has the rest of the scraped information of the dog.
+	Dog dog.Dog
+}
+
+// NewData returns the data of the dog for the templates.
+func NewData(d dog.Dog, date time.Time) Data {
+	return Data{
+		Name:        d.Name,
+		Description: d.Description,
+		URL:         d.URL,
+		AdoptionURL: d.Organization.AdoptionURL,
+		Date:        date,
+		Dog:         d,
+	}
+}
+
+// Templates are the description templates by name.
+type Templates map[string]*template.Template
+
+// Load reads the templates from the .tmpl files in the directory. The name of a template is the name of its file
+// without the extension. Files replace the built-in template of the same name.
+func Load(directory string) (Templates, error) {
+	if _, err := os.Stat(directory); err != nil {
+		return nil, fmt.Errorf("failed to find templates in %s: %w", directory, err)
+	}
+	templates, err := builtIn()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(directory, "*"+Extension))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find templates in %s: %w", directory, err)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template from %s: %w", file, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), Extension)
+		if templates[name], err = parse(name, string(b)); err != nil {
+			return nil, fmt.Errorf("failed to parse template from %s: %w", file, err)
+		}
+	}
+	return templates, nil
+}
+
+// LoadOrDefault reads the templates from the specified directory. If the directory is the default directory and it
+// does not exist, the built-in templates are returned. Any other directory that does not exist is an error.
+func LoadOrDefault(directory string) (Templates, error) {
+	if _, err := os.Stat(directory); os.IsNotExist(err) && filepath.Clean(directory) == DefaultDirectory {
+		return builtIn()
+	}
+	return Load(directory)
+}
+
+// Names returns the sorted names of the templates.
+func (t Templates) Names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the template with the name.
+func (t Templates) Get(name string) (*template.Template, error) {
+	tmpl, ok := t[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q, expected one of %s", name, strings.Join(t.Names(), ", "))
+	}
+	return tmpl, nil
+}
+
+// Default returns the built-in adoption template.
+func Default() *template.Template {
+	return template.Must(parse(Adoption, Defaults[Adoption]))
+}
+
+// Render writes the description of the dog with the template.
+func Render(tmpl *template.Template, data Data) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render description of %s: %w", data.Name, err)
+	}
+	return b.String(), nil
+}
+
+func builtIn() (Templates, error) {
+	templates := make(Templates, len(Defaults))
+	for name, text := range Defaults {
+		tmpl, err := parse(name, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse built-in template %s: %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
+
+func parse(name string, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
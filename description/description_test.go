@@ -0,0 +1,94 @@
+package description_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/description"
+	"pet-spotlight/dog"
+	"pet-spotlight/organization"
+	"strings"
+	"testing"
+	"time"
+)
+
+var bella = dog.Dog{
+	Name:         "Bella",
+	Organization: organization.TwoBlondes,
+	URL:          "https://www.petstablished.com/pets/public/1",
+	Description:  "Bella loves belly rubs.",
+}
+
+func TestRenderAdoption(t *testing.T) {
+	templates, err := description.LoadOrDefault(description.DefaultDirectory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := templates.Get(description.Adoption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := description.Render(tmpl, description.NewData(bella, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Bella loves belly rubs.\n👇👇SUBMIT AN APPLICATION HERE: 👇👇\n" + organization.TwoBlondes.AdoptionURL
+	if desc != expected {
+		t.Errorf("description is %q", desc)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	text := "{{.Name}} - {{.URL}} - {{.Date.Format \"2006-01-02\"}}"
+	if err = ioutil.WriteFile(filepath.Join(dir, "spotlight.tmpl"), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := description.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := strings.Join(templates.Names(), ","); names != "adoption,foster-needed,spotlight,urgent" {
+		t.Errorf("names are %s", names)
+	}
+	tmpl, err := templates.Get("spotlight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2020, time.March, 14, 0, 0, 0, 0, time.UTC)
+	desc, err := description.Render(tmpl, description.NewData(bella, date))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc != "Bella - https://www.petstablished.com/pets/public/1 - 2020-03-14" {
+		t.Errorf("description is %q", desc)
+	}
+	if _, err = templates.Get("missing"); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte("{{.Name"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = description.Load(dir); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestLoadOrDefaultMissing(t *testing.T) {
+	// A directory that is not the default directory must exist
+	if _, err := description.LoadOrDefault("does-not-exist"); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+}
@@ -0,0 +1,59 @@
+package dog
+
+import (
+	"pet-spotlight/organization"
+	"strings"
+	"unicode"
+)
+
+// Dog is a dog listed by an organization. The ID is the ID of the dog on its adoption platform, when the platform has
+// one.
+type Dog struct {
+	ID           string                    `json:"id,omitempty"`
+	Name         string                    `json:"name"`
+	Organization organization.Organization `json:"organization"`
+	URL          string                    `json:"url"`
+	Status       string                    `json:"status"`
+	Foster       bool                      `json:"foster"`
+	Breed        string                    `json:"breed,omitempty"`
+	Age          string                    `json:"age,omitempty"`
+	Sex          string                    `json:"sex,omitempty"`
+	Weight       string                    `json:"weight,omitempty"`
+	Description  string                    `json:"description,omitempty"`
+	ImageURLs    []string                  `json:"imageURLs,omitempty"`
+	VideoURLs    []string                  `json:"videoURLs,omitempty"`
+	Directory    string                    `json:"directory,omitempty"`
+}
+
+// unnamedDirectory is the directory of a dog whose name has nothing that can be used in a directory name.
+const unnamedDirectory = "unnamed"
+
+// Key returns the normalized name of the dog. It is used to match the dog.
+func (d Dog) Key() string {
+	return Key(d.Name)
+}
+
+// Key normalizes the name of a dog.
+func Key(name string) string {
+	return strings.ReplaceAll(strings.TrimSpace(strings.ToLower(name)), "\"", "")
+}
+
+// DirName returns the name of the directory of the dog.
+func (d Dog) DirName() string {
+	return DirName(d.Name)
+}
+
+// DirName returns the normalized name of a dog with everything other than letters, digits, spaces, underscores and
+// dashes replaced by an underscore, so a scraped name such as "Max/Milo" or ".." stays a single directory.
+func DirName(name string) string {
+	dirName := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, Key(name))
+	if len(strings.Trim(dirName, "_ ")) == 0 {
+		return unnamedDirectory
+	}
+	return dirName
+}
@@ -0,0 +1,23 @@
+package dog_test
+
+import (
+	"pet-spotlight/dog"
+	"testing"
+)
+
+func TestDirName(t *testing.T) {
+	tests := map[string]string{
+		"  \"Bella\" ":  "bella",
+		"Max/Milo":      "max_milo",
+		"../x":          "___x",
+		"..":            "unnamed",
+		"Zoë's":         "zoë_s",
+		"Mary-Kate Two": "mary-kate two",
+		`C:\dogs`:       "c__dogs",
+	}
+	for name, expected := range tests {
+		if dirName := dog.DirName(name); dirName != expected {
+			t.Errorf("directory of %q is %q, expected %q", name, dirName, expected)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	"strings"
+)
+
+// Format is a file format the dogs can be exported to.
+type Format string
+
+const (
+	// CSV is comma-separated values.
+	CSV Format = "csv"
+	// JSON is a JSON array of the dogs.
+	JSON Format = "json"
+	// XLSX is an Excel workbook.
+	XLSX Format = "xlsx"
+)
+
+// Formats are all the supported formats.
+var Formats = []Format{CSV, JSON, XLSX}
+
+var header = []string{"Name", "Organization", "Status", "URL", "Breed", "Age", "Sex", "Weight"}
+
+// ParseFormat returns the format with the provided name, ignoring case.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(string(format), name) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported export format %q", name)
+}
+
+// FormatOf returns the format based on the extension of the file.
+func FormatOf(file string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(file), "."))
+}
+
+// Write writes the dogs in the specified format.
+func Write(w io.Writer, format Format, dogs []dog.Dog) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, dogs)
+	case JSON:
+		return WriteJSON(w, dogs)
+	case XLSX:
+		return WriteXLSX(w, dogs)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ToFile writes the dogs to the file. The format is determined by the extension of the file.
+func ToFile(file string, dogs []dog.Dog) error {
+	format, err := FormatOf(file)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", file, err)
+	}
+	if err = Write(f, format, dogs); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to export dogs to %s: %w", file, err)
+	}
+	return f.Close()
+}
+
+// WriteCSV writes the dogs as comma-separated values with a header row and one row per dog.
+func WriteCSV(w io.Writer, dogs []dog.Dog) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, d := range dogs {
+		if err := writer.Write(row(d)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the dogs as a JSON array.
+func WriteJSON(w io.Writer, dogs []dog.Dog) error {
+	if dogs == nil {
+		dogs = []dog.Dog{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dogs)
+}
+
+func row(d dog.Dog) []string {
+	return []string{d.Name, d.Organization.String(), d.Status, d.URL, d.Breed, d.Age, d.Sex, d.Weight}
+}
@@ -0,0 +1,92 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"pet-spotlight/dog"
+	"pet-spotlight/export"
+	"pet-spotlight/organization"
+	"strings"
+	"testing"
+)
+
+var dogs = []dog.Dog{
+	{Name: "Bella", Organization: organization.TwoBlondes, Status: "Foster Me", URL: "https://example.com/bella"},
+	{Name: "Max & \"Maxine\"", Organization: organization.TwoBlondes, Status: "Adopt Me", URL: "https://example.com/max"},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := export.WriteCSV(&b, dogs); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("wrote %d rows", len(records))
+	}
+	if records[2][0] != dogs[1].Name || records[2][2] != "Adopt Me" || records[2][3] != "https://example.com/max" {
+		t.Errorf("row is %v", records[2])
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := export.WriteJSON(&b, dogs); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []dog.Dog
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 2 || decoded[0].Name != "Bella" {
+		t.Errorf("decoded %+v", decoded)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var b bytes.Buffer
+	if err := export.WriteXLSX(&b, dogs); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet string
+	for _, f := range r.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sheet = string(content)
+		}
+	}
+	if !strings.Contains(sheet, `<c r="A3" t="inlineStr"><is><t xml:space="preserve">Max &amp; &#34;Maxine&#34;</t></is></c>`) {
+		t.Errorf("sheet is missing escaped name: %s", sheet)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	format, err := export.FormatOf("boarding.XLSX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != export.XLSX {
+		t.Errorf("format is %s", format)
+	}
+	if _, err = export.FormatOf("boarding.txt"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
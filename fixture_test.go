@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	spotlight "pet-spotlight/http"
+	"pet-spotlight/metadata"
+	"pet-spotlight/organization"
+	"pet-spotlight/photo"
+	"pet-spotlight/profile"
+	"pet-spotlight/progress"
+	"pet-spotlight/source"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureServer serves the recorded Petstablished pages and media in testdata. The widget pages of organization 1 are
+// served from widget-<page>.html and the pages of the dogs from pet-<name>.html. The paths in failures respond with a
+// 503 status that many times before they are served.
+type fixtureServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	pages    []string
+	failures map[string]int
+}
+
+func newFixtureServer(t *testing.T) *fixtureServer {
+	s := &fixtureServer{failures: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/organization/1/widget/dogs", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		s.lock.Lock()
+		s.pages = append(s.pages, page)
+		s.lock.Unlock()
+		http.ServeFile(w, r, filepath.Join("testdata", "widget-"+page+".html"))
+	})
+	mux.HandleFunc("/pets/public/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "pet-"+filepath.Base(r.URL.Path)+".html"))
+	})
+	mux.Handle("/media/", http.FileServer(http.Dir("testdata")))
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		failures := s.failures[r.URL.Path]
+		s.failures[r.URL.Path] = failures - 1
+		s.lock.Unlock()
+		if failures > 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// sourceWithClient creates the source of the fixture organization that requests the pages with the client.
+func (s *fixtureServer) sourceWithClient(client *http.Client) source.Source {
+	org := organization.Organization{ID: "1", Name: "Fixture Rescue", BaseURL: s.URL, AdoptionURL: "https://rescue.org/adopt"}
+	return source.NewPetstablished(org, profile.Default, client)
+}
+
+func (s *fixtureServer) source() source.Source {
+	return s.sourceWithClient(s.Client())
+}
+
+func (s *fixtureServer) requestedPages() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.pages...)
+}
+
+func collectErrors() (chan error, func() []error) {
+	errorChannel := make(chan error, 100)
+	return errorChannel, func() []error {
+		close(errorChannel)
+		var errs []error
+		for err := range errorChannel {
+			errs = append(errs, err)
+		}
+		return errs
+	}
+}
+
+// collectProgress keeps the state of the downloads from the events sent to the progress channel. The messages of the
+// events are sent once the channel is closed.
+func collectProgress() (chan progress.Event, chan []string, *progress.Tracker) {
+	progressChannel := make(chan progress.Event, 100)
+	messages := make(chan []string, 1)
+	tracker := progress.NewTracker()
+	go func() {
+		var collected []string
+		for event := range progressChannel {
+			tracker.Update(event)
+			if len(event.Message) > 0 {
+				collected = append(collected, event.Message)
+			}
+		}
+		messages <- collected
+	}()
+	return progressChannel, messages, tracker
+}
+
+func TestRunGetFostersFixtures(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	errorChannel, errs := collectErrors()
+	fosters, err := RunGetFosters(context.Background(), server.source(), errorChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := errs(); len(e) > 0 {
+		t.Errorf("unexpected errors %v", e)
+	}
+	// The listing stops at the page saying there are no more pets
+	if pages := strings.Join(server.requestedPages(), ","); pages != "1,2,3" {
+		t.Errorf("requested pages %s", pages)
+	}
+	sort.Slice(fosters, func(i, j int) bool {
+		return fosters[i].Name < fosters[j].Name
+	})
+	if len(fosters) != 2 || fosters[0].Name != "\"Luna\"" || fosters[1].Name != "Max" {
+		t.Fatalf("fosters are %+v", fosters)
+	}
+	// Without the show less text the whole description is kept
+	if fosters[1].Description != "Max is a couch potato who needs a place to stay." {
+		t.Errorf("description of max is %q", fosters[1].Description)
+	}
+	if strings.TrimSpace(fosters[0].Description) != "Luna is shy at first." {
+		t.Errorf("description of luna is %q", fosters[0].Description)
+	}
+	if fosters[1].Organization.Name != "Fixture Rescue" || fosters[1].URL != server.URL+"/pets/public/max" {
+		t.Errorf("max is %+v", fosters[1])
+	}
+}
+
+func TestRunGetBoardingListUnsupportedSource(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	for _, env := range []string{source.PetfinderClientIDEnv, source.PetfinderClientSecretEnv} {
+		defer os.Setenv(env, os.Getenv(env))
+		if err := os.Setenv(env, "fixture"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orgs := []organization.Organization{
+		{ID: "1", Name: "Fixture Rescue", BaseURL: server.URL},
+		{ID: "TX1", Name: "Petfinder Rescue", Source: organization.SourcePetfinder, BaseURL: server.URL},
+	}
+	errorChannel, errs := collectErrors()
+	fosters, err := RunGetBoardingList(context.Background(), orgs, profile.Default, server.Client(), errorChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fosters of the other organizations are still listed, and the missing organization is reported
+	if len(fosters) != 2 {
+		t.Errorf("fosters are %+v", fosters)
+	}
+	if e := errs(); len(e) != 1 || !errors.Is(e[0], source.ErrFostersNotSupported) {
+		t.Errorf("errors are %v", e)
+	}
+}
+
+func TestRunDogDownloadsFixtures(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	errorChannel, errs := collectErrors()
+	progressChannel, messages, tracker := collectProgress()
+	opts := DownloadOptions{Client: server.Client()}
+	matches, err := RunDogDownloads(context.Background(), server.source(), "bella, rex, fido", dir, opts, progressChannel, errorChannel)
+	collected := <-messages
+	if !errors.Is(err, ErrMissing) || !strings.HasSuffix(err.Error(), ": fido") {
+		t.Fatalf("expected fido to be missing, got %v", err)
+	}
+	// Rex does not have a page
+	if e := errs(); len(e) != 1 || !strings.Contains(e[0].Error(), "/pets/public/rex") {
+		t.Errorf("errors are %v", e)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("matched %d dogs", len(matches))
+	}
+	if report := collected[len(collected)-1]; report != "\nFailed to find:\nfido" {
+		t.Errorf("report is %q", report)
+	}
+	// Rex failed and bella is complete, so there is nothing left to download
+	if fraction := tracker.Fraction(); fraction != 1 {
+		t.Errorf("fraction is %f", fraction)
+	}
+	statuses := tracker.Statuses()
+	if len(statuses) != 2 || statuses[0].Phase != progress.Complete || statuses[0].FilesDone != 2 || statuses[1].Phase != progress.Failed {
+		t.Errorf("statuses are %+v", statuses)
+	}
+	var bella dog.Dog
+	for _, match := range matches {
+		if match.Name == "Bella" {
+			bella = match
+		}
+	}
+	if bella.Age != "2 years" || bella.Weight != "30 lbs" || bella.Sex != "Female" || len(bella.ImageURLs) != 2 {
+		t.Errorf("bella is %+v", bella)
+	}
+	desc, err := ioutil.ReadFile(filepath.Join(dir, "bella", "description.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(desc), "Bella loves long walks and belly rubs. \n") || !strings.HasSuffix(string(desc), "https://rescue.org/adopt") {
+		t.Errorf("description is %q", desc)
+	}
+	m, err := metadata.Read(filepath.Join(dir, "bella"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Media) != 2 || m.Media[0].File != "image-0.png" || m.Media[1].File != "image-1.png" {
+		t.Fatalf("media is %+v", m.Media)
+	}
+	if m.Media[0].SourceURL != server.URL+"/media/bella-0.png" || m.Media[0].Width != 4 || m.Media[0].Height != 3 {
+		t.Errorf("media is %+v", m.Media[0])
+	}
+}
+
+// cancelTransport cancels the downloads once the first path with the prefix is requested.
+type cancelTransport struct {
+	prefix    string
+	cancel    context.CancelFunc
+	transport http.RoundTripper
+}
+
+func (t cancelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Path, t.prefix) {
+		t.cancel()
+	}
+	return t.transport.RoundTrip(req)
+}
+
+func TestRunDogDownloadsCanceled(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	errorChannel, errs := collectErrors()
+	progressChannel, messages, tracker := collectProgress()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &http.Client{Transport: cancelTransport{prefix: "/media/", cancel: cancel, transport: server.Client().Transport}}
+	opts := DownloadOptions{Client: client, Collage: &photo.DefaultCollage}
+	_, err = RunDogDownloads(ctx, server.source(), "bella", dir, opts, progressChannel, errorChannel)
+	collected := <-messages
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+	// Stopped downloads are not errors
+	if e := errs(); len(e) > 0 {
+		t.Errorf("unexpected errors %v", e)
+	}
+	if report := collected[len(collected)-1]; report != "\nCanceled\nNot completed:\nBella" {
+		t.Errorf("report is %q", report)
+	}
+	if statuses := tracker.Statuses(); len(statuses) != 1 || statuses[0].Phase != progress.Canceled {
+		t.Errorf("statuses are %+v", statuses)
+	}
+	// Neither the images, partial files nor the collage are left behind
+	files, err := ioutil.ReadDir(filepath.Join(dir, "bella"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.Name() != "description.txt" && file.Name() != metadata.FileName {
+			t.Errorf("unexpected file %s", file.Name())
+		}
+	}
+}
+
+func TestRunDogDownloadsCanceledDetail(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	errorChannel, errs := collectErrors()
+	progressChannel, messages, tracker := collectProgress()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The download is canceled while the page of the dog is loading
+	client := &http.Client{Transport: cancelTransport{prefix: "/pets/public/", cancel: cancel, transport: server.Client().Transport}}
+	_, err = RunDogDownloads(ctx, server.sourceWithClient(client), "bella", dir, DownloadOptions{Client: client}, progressChannel, errorChannel)
+	<-messages
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+	if e := errs(); len(e) > 0 {
+		t.Errorf("unexpected errors %v", e)
+	}
+	if statuses := tracker.Statuses(); len(statuses) != 1 || statuses[0].Phase != progress.Canceled || statuses[0].Fraction != 1 {
+		t.Errorf("statuses are %+v", statuses)
+	}
+}
+
+func TestRunDogDownloadsRefreshCanceled(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	errorChannel, errs := collectErrors()
+	progressChannel, messages, _ := collectProgress()
+	if _, err = RunDogDownloads(context.Background(), server.source(), "bella", dir, DownloadOptions{Client: server.Client()}, progressChannel, errorChannel); err != nil {
+		t.Fatal(err)
+	}
+	<-messages
+	// The changed image is downloaded again by the refresh, which is canceled before it is done
+	if err = ioutil.WriteFile(filepath.Join(dir, "bella", "image-0.png"), []byte("changed"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &http.Client{Transport: cancelTransport{prefix: "/media/", cancel: cancel, transport: server.Client().Transport}}
+	progressChannel, messages, _ = collectProgress()
+	_, err = RunDogDownloads(ctx, server.source(), "bella", dir, DownloadOptions{Client: client, Refresh: true}, progressChannel, errorChannel)
+	<-messages
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+	if e := errs(); len(e) > 0 {
+		t.Errorf("unexpected errors %v", e)
+	}
+	// The record of the image that was not downloaded again is kept
+	m, err := metadata.Read(filepath.Join(dir, "bella"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Media) != 2 || m.Media[0].File != "image-0.png" || m.Media[1].File != "image-1.png" {
+		t.Errorf("media is %+v", m.Media)
+	}
+}
+
+func TestRunDogDownloadsRetries(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	server.failures["/pets/public/bella"] = 1
+	server.failures["/media/bella-0.png"] = 2
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	errorChannel, errs := collectErrors()
+	progressChannel, messages, _ := collectProgress()
+	client := spotlight.WithRetry(server.Client(), spotlight.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond})
+	opts := DownloadOptions{Client: client}
+	_, err = RunDogDownloads(context.Background(), server.sourceWithClient(client), "bella", dir, opts, progressChannel, errorChannel)
+	collected := <-messages
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := errs(); len(e) > 0 {
+		t.Errorf("unexpected errors %v", e)
+	}
+	// Each retry is logged against the dog
+	var retries []string
+	for _, message := range collected {
+		if strings.HasPrefix(message, "Bella: Retrying") {
+			retries = append(retries, message)
+		}
+	}
+	if len(retries) != 3 || !strings.Contains(retries[2], "/media/bella-0.png") || !strings.Contains(retries[2], "attempt 3 of 3") {
+		t.Errorf("retries are %v", retries)
+	}
+	m, err := metadata.Read(filepath.Join(dir, "bella"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Media) != 2 {
+		t.Errorf("media is %+v", m.Media)
+	}
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/io"
+	"strings"
+	"time"
+)
+
+// Defaults of the cache.
+const (
+	DefaultCacheDirectory = "cache"
+	DefaultCacheMaxAge    = 15 * time.Minute
+)
+
+// ErrNotCached is returned in offline mode when the response of a request is not in the cache.
+var ErrNotCached = errors.New("not in the cache")
+
+// CacheConfig is the settings of the cache of the pages.
+type CacheConfig struct {
+	// Directory is where the pages are cached. Nothing is cached when it is empty.
+	Directory string `json:"directory"`
+	// MaxAge is how long a cached page is used without asking the server if it has changed.
+	MaxAge Duration `json:"maxAge"`
+	// Offline only serves pages from the cache, no matter how old, and fails the requests of the pages that are not
+	// cached.
+	Offline bool `json:"offline"`
+}
+
+// CacheTransport caches the pages of the transport on disk. Only successful GET responses with an HTML or JSON body are
+// cached, so images and videos are always downloaded. A cached page older than the max age is revalidated with the
+// ETag and Last-Modified headers of the page, and it is only downloaded again when it has changed.
+type CacheTransport struct {
+	Config CacheConfig
+	// Transport makes the requests. The default transport is used when it is nil.
+	Transport http.RoundTripper
+}
+
+// RoundTrip returns the cached response of the request when it is fresh and makes the request otherwise.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if len(t.Config.Directory) == 0 || req.Method != http.MethodGet || len(req.Header.Get("Range")) > 0 {
+		if t.Config.Offline {
+			return nil, fmt.Errorf("request url: %s, %w", req.URL, ErrNotCached)
+		}
+		return transport.RoundTrip(req)
+	}
+	file := t.file(req)
+	cached, storedAt, err := readCached(file, req)
+	if err != nil {
+		if t.Config.Offline {
+			return nil, fmt.Errorf("request url: %s, %w", req.URL, ErrNotCached)
+		}
+		return t.request(transport, file, req)
+	}
+	if t.Config.Offline || time.Since(storedAt) < time.Duration(t.Config.MaxAge) {
+		return cached, nil
+	}
+	etag := cached.Header.Get("ETag")
+	lastModified := cached.Header.Get("Last-Modified")
+	if len(etag) == 0 && len(lastModified) == 0 {
+		io.CloseResource(cached.Body)
+		return t.request(transport, file, req)
+	}
+	conditionalReq := req.Clone(req.Context())
+	if len(etag) > 0 {
+		conditionalReq.Header.Set("If-None-Match", etag)
+	}
+	if len(lastModified) > 0 {
+		conditionalReq.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := transport.RoundTrip(conditionalReq)
+	if err != nil {
+		io.CloseResource(cached.Body)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		io.CloseResource(cached.Body)
+		return t.store(file, resp)
+	}
+	io.CloseResource(resp.Body)
+	// The cached page is fresh again
+	now := time.Now()
+	os.Chtimes(file, now, now)
+	return cached, nil
+}
+
+// file returns the file the response of the request is cached in.
+func (t *CacheTransport) file(req *http.Request) string {
+	hash := sha256.Sum256([]byte(req.URL.String()))
+	return filepath.Join(t.Config.Directory, hex.EncodeToString(hash[:]))
+}
+
+// request makes the request and caches the response in the file.
+func (t *CacheTransport) request(transport http.RoundTripper, file string, req *http.Request) (*http.Response, error) {
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(file, resp)
+}
+
+// store caches the response when it is cacheable. A response that cannot be cached is returned all the same.
+func (t *CacheTransport) store(file string, resp *http.Response) (*http.Response, error) {
+	if !isCacheable(resp) {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	io.CloseResource(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	resp.Header.Del("Transfer-Encoding")
+	dump, err := httputil.DumpResponse(resp, true)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, nil
+	}
+	// A page that cannot be cached is downloaded again next time, so the errors of writing the cache are ignored
+	if err = os.MkdirAll(t.Config.Directory, os.ModePerm); err != nil {
+		return resp, nil
+	}
+	tmpFile, err := ioutil.TempFile(t.Config.Directory, filepath.Base(file)+"-*.tmp")
+	if err != nil {
+		return resp, nil
+	}
+	_, err = tmpFile.Write(dump)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+	}
+	return resp, nil
+}
+
+// readCached reads the cached response of the request from the file and returns when it was stored.
+func readCached(file string, req *http.Request) (*http.Response, time.Time, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read cached response from %s: %w", file, err)
+	}
+	return resp, info.ModTime(), nil
+}
+
+// isCacheable determines if the response is a page that may be cached.
+func isCacheable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "text/html" || mediaType == "application/json")
+}
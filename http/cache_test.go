@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	spotlight "pet-spotlight/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var lock sync.Mutex
+	requests := make(map[string]int)
+	conditional := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.String()]++
+		lock.Unlock()
+		switch r.URL.Path {
+		case "/widget/dogs":
+			if r.Header.Get("If-None-Match") == `"page-1"` {
+				conditional++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"page-1"`)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html>Bella</html>"))
+		case "/image.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg"))
+		}
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	get := func(client *http.Client, url string) (string, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		return string(b), err
+	}
+	config := spotlight.CacheConfig{Directory: dir, MaxAge: spotlight.Duration(time.Hour)}
+	client := &http.Client{Transport: &spotlight.CacheTransport{Config: config}}
+	page := server.URL + "/widget/dogs?page=1"
+	for i := 0; i < 2; i++ {
+		body, err := get(client, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "<html>Bella</html>" {
+			t.Errorf("body is %q", body)
+		}
+	}
+	// A fresh page is not requested again
+	if requests["/widget/dogs?page=1"] != 1 {
+		t.Errorf("page was requested %d times", requests["/widget/dogs?page=1"])
+	}
+	// A stale page is revalidated with its ETag
+	config.MaxAge = 0
+	client = &http.Client{Transport: &spotlight.CacheTransport{Config: config}}
+	body, err := get(client, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "<html>Bella</html>" || conditional != 1 {
+		t.Errorf("body is %q after %d conditional requests", body, conditional)
+	}
+	// Images are not cached
+	for i := 0; i < 2; i++ {
+		if _, err = get(client, server.URL+"/image.jpg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests["/image.jpg"] != 2 {
+		t.Errorf("image was requested %d times", requests["/image.jpg"])
+	}
+	// Offline only the cached pages are served, no matter how old
+	config.Offline = true
+	client = &http.Client{Transport: &spotlight.CacheTransport{Config: config}}
+	if body, err = get(client, page); err != nil || body != "<html>Bella</html>" {
+		t.Errorf("offline body is %q: %v", body, err)
+	}
+	if _, err = get(client, server.URL+"/widget/dogs?page=2"); !errors.Is(err, spotlight.ErrNotCached) {
+		t.Errorf("expected not cached error, got %v", err)
+	}
+	if requests["/widget/dogs?page=1"] != 2 || requests["/widget/dogs?page=2"] != 0 {
+		t.Errorf("requests are %v", requests)
+	}
+}
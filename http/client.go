@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultClientFile is the file the client settings are loaded from when no file is specified.
+const DefaultClientFile = "client.json"
+
+// DefaultUserAgent is the User-Agent header of the requests when none is configured.
+const DefaultUserAgent = "pet-spotlight-ui"
+
+// DefaultClientConfig is the client settings used when no settings are configured.
+var DefaultClientConfig = ClientConfig{
+	ConnectTimeout: Duration(10 * time.Second),
+	ReadTimeout:    Duration(30 * time.Second),
+	UserAgent:      DefaultUserAgent,
+	Limits:         DefaultHostLimits,
+	Cache:          CacheConfig{Directory: DefaultCacheDirectory, MaxAge: Duration(DefaultCacheMaxAge)},
+}
+
+// ClientConfig is the settings of the client that makes all the requests, for pages as well as images and videos.
+type ClientConfig struct {
+	// ConnectTimeout is the longest time to connect to a server, including the TLS handshake.
+	ConnectTimeout Duration `json:"connectTimeout"`
+	// ReadTimeout is the longest time to wait for the server to send anything, either the response headers or more of
+	// the body. A large video keeps downloading as long as the server keeps sending.
+	ReadTimeout Duration `json:"readTimeout"`
+	// Timeout is the longest time a request may take overall, including reading the body and retrying. There is no
+	// limit when it is zero.
+	Timeout Duration `json:"timeout"`
+	// Proxy is the URL of the proxy to make the requests through. The proxy of the environment (HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY) is used when it is empty.
+	Proxy string `json:"proxy"`
+	// CAFile is the PEM file of the certificate authorities to trust on top of the system certificate authorities.
+	CAFile string `json:"caFile"`
+	// UserAgent is the User-Agent header of the requests.
+	UserAgent string `json:"userAgent"`
+	// Limits is how politely each host is requested. The first limit matching the host is used. Hosts without a
+	// matching limit are not limited.
+	Limits []HostLimit `json:"limits"`
+	// RobotsTxt respects the robots.txt of the hosts. Requests it does not allow fail.
+	RobotsTxt bool `json:"robotsTxt"`
+	// Cache is the settings of the cache of the pages.
+	Cache CacheConfig `json:"cache"`
+}
+
+// Duration is a duration written as text in JSON, e.g. "30s" or "1m30s".
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration from text.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err != nil {
+		return fmt.Errorf("duration must be text, e.g. \"30s\": %w", err)
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalJSON writes the duration as text.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadClientConfig reads the client settings from the specified file. The settings missing from the file are the
+// default settings.
+func LoadClientConfig(file string) (ClientConfig, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ClientConfig{}, fmt.Errorf("failed to read client settings from %s: %w", file, err)
+	}
+	config := DefaultClientConfig
+	// The limits are decoded into a new slice, so the default limits are not overwritten
+	config.Limits = nil
+	if err = json.Unmarshal(b, &config); err != nil {
+		return ClientConfig{}, fmt.Errorf("failed to parse client settings from %s: %w", file, err)
+	}
+	if config.Limits == nil {
+		config.Limits = DefaultHostLimits
+	}
+	return config, nil
+}
+
+// LoadClientConfigOrDefault reads the client settings from the specified file. If the file is the default file and it
+// does not exist, the default settings are returned. Any other file that does not exist is an error.
+func LoadClientConfigOrDefault(file string) (ClientConfig, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) && filepath.Clean(file) == DefaultClientFile {
+		return DefaultClientConfig, nil
+	}
+	return LoadClientConfig(file)
+}
+
+// NewClient creates the client with the settings. Pages are served from the cache when they are fresh, failed requests
+// are retried with the policy, and each attempt waits for the limit of its host.
+func NewClient(config ClientConfig, policy RetryPolicy) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if len(config.Proxy) > 0 {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil || len(proxyURL.Host) == 0 {
+			return nil, fmt.Errorf("invalid proxy URL %q", config.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	tlsConfig := &tls.Config{}
+	if len(config.CAFile) > 0 {
+		pool, err := loadCertPool(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	dialer := &net.Dialer{Timeout: time.Duration(config.ConnectTimeout), KeepAlive: 30 * time.Second}
+	readTimeout := time.Duration(config.ReadTimeout)
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, address)
+			if err != nil || readTimeout <= 0 {
+				return conn, err
+			}
+			return &readTimeoutConn{Conn: conn, timeout: readTimeout}, nil
+		},
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   time.Duration(config.ConnectTimeout),
+		ResponseHeaderTimeout: readTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	var roundTripper http.RoundTripper = transport
+	if len(config.UserAgent) > 0 {
+		roundTripper = &userAgentTransport{userAgent: config.UserAgent, transport: roundTripper}
+	}
+	roundTripper = &PoliteTransport{Limits: config.Limits, RobotsTxt: config.RobotsTxt, UserAgent: config.UserAgent, Transport: roundTripper}
+	roundTripper = &RetryTransport{Policy: policy, Transport: roundTripper}
+	if len(config.Cache.Directory) > 0 || config.Cache.Offline {
+		roundTripper = &CacheTransport{Config: config.Cache, Transport: roundTripper}
+	}
+	return &http.Client{
+		Transport: roundTripper,
+		Timeout:   time.Duration(config.Timeout),
+	}, nil
+}
+
+// loadCertPool returns the system certificate authorities with the certificate authorities of the PEM file.
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate authorities from %s: %w", file, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + file)
+	}
+	return pool, nil
+}
+
+// readTimeoutConn fails a read when nothing is received within the timeout.
+type readTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+// Read reads from the connection, waiting at most the timeout.
+func (c *readTimeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// userAgentTransport sets the User-Agent header of the requests, replacing the header of the scraper.
+type userAgentTransport struct {
+	userAgent string
+	transport http.RoundTripper
+}
+
+// RoundTrip makes a copy of the request with the User-Agent header.
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,159 @@
+package http_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	spotlight "pet-spotlight/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadClientConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "client.json")
+	if err = ioutil.WriteFile(file, []byte(`{"timeout": "2m", "proxy": "http://proxy:3128"}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config, err := spotlight.LoadClientConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The settings missing from the file are the default settings
+	if config.Timeout != spotlight.Duration(2*time.Minute) || config.Proxy != "http://proxy:3128" || config.ConnectTimeout != spotlight.DefaultClientConfig.ConnectTimeout || config.UserAgent != spotlight.DefaultUserAgent {
+		t.Errorf("config is %+v", config)
+	}
+	if err = ioutil.WriteFile(file, []byte(`{"timeout": 120}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = spotlight.LoadClientConfig(file); err == nil {
+		t.Error("expected error for a duration that is not text")
+	}
+	if err = ioutil.WriteFile(file, []byte(`{"limits": [{"hosts": "*.example.com", "parallelism": 1, "delay": "2s"}]}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config, err = spotlight.LoadClientConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Limits) != 1 || config.Limits[0] != (spotlight.HostLimit{Hosts: "*.example.com", Parallelism: 1, Delay: spotlight.Duration(2 * time.Second)}) {
+		t.Errorf("limits are %+v", config.Limits)
+	}
+	// The default limits are not overwritten by the limits of the file
+	if spotlight.DefaultHostLimits[0].Hosts == "*.example.com" {
+		t.Errorf("default limits are %+v", spotlight.DefaultHostLimits)
+	}
+	if err = ioutil.WriteFile(file, []byte(`{"cache": {"offline": true}}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config, err = spotlight.LoadClientConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Cache.Offline || config.Cache.Directory != spotlight.DefaultCacheDirectory || config.Cache.MaxAge != spotlight.Duration(spotlight.DefaultCacheMaxAge) {
+		t.Errorf("cache is %+v", config.Cache)
+	}
+	config, err = spotlight.LoadClientConfigOrDefault(spotlight.DefaultClientFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config, spotlight.DefaultClientConfig) {
+		t.Errorf("config is %+v", config)
+	}
+	// A file that is not the default file must exist
+	if _, err = spotlight.LoadClientConfigOrDefault(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	var userAgent string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		if r.URL.Path == "/slow" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The certificate of the test server is only trusted when it is in the CA file
+	caFile := filepath.Join(dir, "ca.pem")
+	if err = ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config := spotlight.DefaultClientConfig
+	config.ReadTimeout = spotlight.Duration(50 * time.Millisecond)
+	untrusted, err := spotlight.NewClient(config, spotlight.RetryPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = untrusted.Get(server.URL); err == nil {
+		t.Error("expected error for an untrusted certificate")
+	}
+	config.CAFile = caFile
+	config.UserAgent = "shelter-bot"
+	client, err := spotlight.NewClient(config, spotlight.RetryPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if userAgent != "shelter-bot" {
+		t.Errorf("user agent is %q", userAgent)
+	}
+	// The server stops sending for longer than the read timeout
+	resp, err = client.Get(server.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err = ioutil.ReadAll(resp.Body); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestNewClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+	config := spotlight.DefaultClientConfig
+	config.Proxy = proxy.URL
+	client, err := spotlight.NewClient(config, spotlight.RetryPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://shelter.invalid/dogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if proxied != "http://shelter.invalid/dogs" {
+		t.Errorf("proxied %q", proxied)
+	}
+	config.Proxy = "not a url"
+	if _, err = spotlight.NewClient(config, spotlight.RetryPolicy{}); err == nil {
+		t.Error("expected error for an invalid proxy")
+	}
+}
@@ -1,28 +1,95 @@
 package http
 
 import (
+	"context"
 	"fmt"
+	goio "io"
 	"net/http"
 	"os"
 	"pet-spotlight/io"
 )
 
-// Download downloads the file from the specified URL and saves to the provided path as the specified file
-// name.
-func Download(url string, path string, fileName string) error {
-	resp, err := http.Get(url)
+// partSuffix is added to the name of a file while it is being downloaded. Only a completely downloaded file has its
+// final name, so an interrupted download never leaves a partial file behind that looks complete.
+const partSuffix = ".part"
+
+// File is a file that has been downloaded.
+type File struct {
+	Path        string
+	SourceURL   string
+	ContentType string
+}
+
+// Download downloads the image from the specified URL with the client and saves to the provided path as the
+// specified file name. The default client is used when the client is nil. The download stops when the context is
+// done, and the partially written file is removed. The extension of the file is determined
+// from the content of the image. When a format is provided, the image is converted to the format. ErrNotImage is
+// returned when the content is not an image. The progress of writing the file is reported when the report is not nil.
+func Download(ctx context.Context, client *http.Client, url string, path string, name string, format string, report func(io.Progress)) (File, error) {
+	resp, err := get(ctx, orDefault(client), url)
 	if err != nil {
-		return fmt.Errorf("failed to get image from %s: %w", url, err)
+		return File{}, fmt.Errorf("failed to get image from %s: %w", url, err)
 	}
 	defer io.CloseResource(resp.Body)
-	filePath := fmt.Sprintf("%s/%s", path, fileName)
-	f, err := os.Create(filePath)
+	if resp.StatusCode != http.StatusOK {
+		return File{}, fmt.Errorf("failed to get image from %s: status code %d", url, resp.StatusCode)
+	}
+	content, imageType, err := sniffImage(resp)
 	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filePath, err)
+		return File{}, fmt.Errorf("failed to get image from %s: %w", url, err)
+	}
+	var body goio.Reader = content
+	size := resp.ContentLength
+	if format != FormatOriginal && formatContentTypes[format] != imageType {
+		converted, convertedType, err := convertImage(content, format)
+		if err != nil {
+			return File{}, fmt.Errorf("failed to convert image from %s: %w", url, err)
+		}
+		body, imageType, size = converted, convertedType, int64(converted.Len())
 	}
-	defer io.CloseResource(f)
-	if err = io.CopyToFile(resp.Body, f); err != nil {
+	filePath := fmt.Sprintf("%s/%s%s", path, name, ImageExtension(imageType))
+	if err = saveToFile(body, filePath, size, report); err != nil {
+		return File{}, err
+	}
+	return File{Path: filePath, SourceURL: url, ContentType: imageType}, nil
+}
+
+// orDefault returns the client, or the default client when it is nil.
+func orDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
+
+// get requests the URL with the client. The request is canceled when the context is done.
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// saveToFile copies the content to a partial file and renames it to the file path once all the content is copied. The
+// size is the size of the content, or a negative number when it is not known.
+func saveToFile(content goio.Reader, filePath string, size int64, report func(io.Progress)) error {
+	partPath := filePath + partSuffix
+	f, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", partPath, err)
+	}
+	if err = io.CopyToFileWithProgress(content, f, size, report); err != nil {
+		io.CloseResource(f)
+		os.Remove(partPath)
 		return err
 	}
+	if err = f.Close(); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to close file %s: %w", partPath, err)
+	}
+	if err = os.Rename(partPath, filePath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", partPath, filePath, err)
+	}
 	return nil
 }
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLength is the number of bytes used to detect the content type.
+const sniffLength = 512
+
+// ErrNotImage is returned when the content downloaded is not an image, e.g. an HTML error page.
+var ErrNotImage = errors.New("content is not an image")
+
+// Image formats images can be converted to.
+const (
+	FormatOriginal = ""
+	FormatJPEG     = "jpeg"
+	FormatPNG      = "png"
+	FormatGIF      = "gif"
+)
+
+// ImageFormats are the formats images can be converted to.
+var ImageFormats = []string{FormatJPEG, FormatPNG, FormatGIF}
+
+var extensions = map[string]string{
+	"image/bmp":  ".bmp",
+	"image/gif":  ".gif",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var formatContentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+	FormatGIF:  "image/gif",
+}
+
+// ParseImageFormat returns the image format with the provided name, ignoring case. "jpg" is the same as "jpeg" and an
+// empty name or "original" keeps the original format.
+func ParseImageFormat(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case FormatOriginal, "original":
+		return FormatOriginal, nil
+	case "jpg":
+		return FormatJPEG, nil
+	}
+	if _, ok := formatContentTypes[name]; !ok {
+		return "", fmt.Errorf("unsupported image format %q", name)
+	}
+	return name, nil
+}
+
+// DetectImageType determines the content type of the image from the header and the first bytes of the content. The
+// bytes win over the header. When the bytes are not recognized, the header is used. An error is returned when the
+// content is not an image.
+func DetectImageType(header string, content []byte) (string, error) {
+	sniffed := contentType(http.DetectContentType(content))
+	if _, ok := extensions[sniffed]; ok {
+		return sniffed, nil
+	}
+	if sniffed != "application/octet-stream" {
+		return "", fmt.Errorf("%w: detected %s", ErrNotImage, sniffed)
+	}
+	declared := contentType(header)
+	if _, ok := extensions[declared]; ok {
+		return declared, nil
+	}
+	return "", fmt.Errorf("%w: content type is %q", ErrNotImage, header)
+}
+
+// ImageExtension returns the file extension for the content type of the image.
+func ImageExtension(contentType string) string {
+	return extensions[contentType]
+}
+
+func contentType(value string) string {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+	return mediaType
+}
+
+// sniffImage detects the content type of the image in the response. The returned reader has all the content of the
+// response body.
+func sniffImage(resp *http.Response) (*bufio.Reader, string, error) {
+	reader := bufio.NewReaderSize(resp.Body, sniffLength)
+	// An error is returned when the body is shorter than the sniff length, the bytes that were read are still sniffed
+	content, _ := reader.Peek(sniffLength)
+	imageType, err := DetectImageType(resp.Header.Get("Content-Type"), content)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, imageType, nil
+}
+
+// convertImage decodes the image and encodes it in the format. The converted image and its content type are returned.
+func convertImage(content *bufio.Reader, format string) (*bytes.Buffer, string, error) {
+	img, _, err := image.Decode(content)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	var buffer bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 90})
+	case FormatPNG:
+		err = png.Encode(&buffer, img)
+	case FormatGIF:
+		err = gif.Encode(&buffer, img, nil)
+	default:
+		err = fmt.Errorf("unsupported image format %q", format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode image as %s: %w", format, err)
+	}
+	return &buffer, formatContentTypes[format], nil
+}
@@ -0,0 +1,128 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	spotlight "pet-spotlight/http"
+	"pet-spotlight/io"
+	"testing"
+)
+
+func jpegImage(t *testing.T) []byte {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, image.NewRGBA(image.Rect(0, 0, 2, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestDetectImageType(t *testing.T) {
+	imageType, err := spotlight.DetectImageType("image/png", jpegImage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageType != "image/jpeg" {
+		t.Errorf("image type is %s", imageType)
+	}
+	imageType, err = spotlight.DetectImageType("image/webp; charset=binary", []byte{0, 1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageType != "image/webp" {
+		t.Errorf("image type is %s", imageType)
+	}
+	_, err = spotlight.DetectImageType("image/png", []byte("<!DOCTYPE html><html><body>Not Found</body></html>"))
+	if !errors.Is(err, spotlight.ErrNotImage) {
+		t.Errorf("expected not an image error, got %v", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	content := jpegImage(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("<html><body>Error</body></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(content)
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var last io.Progress
+	file, err := spotlight.Download(context.Background(), server.Client(), server.URL+"/image.png", dir, "image-0", spotlight.FormatOriginal, func(p io.Progress) {
+		last = p
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Path != dir+"/image-0.jpg" || file.ContentType != "image/jpeg" {
+		t.Errorf("downloaded %+v", file)
+	}
+	// The size is known from the content length of the response
+	if last.Written != int64(len(content)) || last.Total != int64(len(content)) {
+		t.Errorf("last progress is %+v", last)
+	}
+
+	file, err = spotlight.Download(context.Background(), server.Client(), server.URL+"/image.png", dir, "image-1", spotlight.FormatPNG, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Path != dir+"/image-1.png" || file.ContentType != "image/png" {
+		t.Errorf("downloaded %+v", file)
+	}
+	if _, err = os.Stat(file.Path); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = spotlight.Download(context.Background(), server.Client(), server.URL+"/missing.png", dir, "image-2", spotlight.FormatOriginal, nil); !errors.Is(err, spotlight.ErrNotImage) {
+		t.Errorf("expected not an image error, got %v", err)
+	}
+	if _, err = os.Stat(dir + "/image-2.png"); !os.IsNotExist(err) {
+		t.Error("expected no file for the error page")
+	}
+}
+
+func TestDownloadCanceled(t *testing.T) {
+	content := jpegImage(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(content)
+		w.(http.Flusher).Flush()
+		// Cancel once part of the image is sent and never send the rest
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err = spotlight.Download(ctx, server.Client(), server.URL+"/image.jpg", dir, "image-0", spotlight.FormatOriginal, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("expected partial files to be removed, got %v", files)
+	}
+}
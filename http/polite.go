@@ -0,0 +1,266 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/temoto/robotstxt"
+	goio "io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path"
+	"pet-spotlight/io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is returned when the robots.txt of the host does not allow the request.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// HostLimit is how politely the hosts matching the pattern are requested. Each host matching the pattern is limited
+// on its own.
+type HostLimit struct {
+	// Hosts is the pattern of the host names, e.g. "*.petstablished.com". "*" matches any host.
+	Hosts string `json:"hosts"`
+	// Parallelism is the most requests made to a host at the same time. There is no limit when it is zero.
+	Parallelism int `json:"parallelism"`
+	// Delay is the least time between the start of two requests to a host.
+	Delay Duration `json:"delay"`
+	// RandomDelay is the most time randomly added to the delay.
+	RandomDelay Duration `json:"randomDelay"`
+}
+
+// DefaultHostLimits are the limits of the hosts when none are configured. The listing pages of Petstablished are
+// requested the most carefully, the image CDNs are made to serve many files and YouTube is in between.
+var DefaultHostLimits = []HostLimit{
+	{Hosts: "petstablished.com", Parallelism: 2, Delay: Duration(500 * time.Millisecond), RandomDelay: Duration(500 * time.Millisecond)},
+	{Hosts: "*.petstablished.com", Parallelism: 2, Delay: Duration(500 * time.Millisecond), RandomDelay: Duration(500 * time.Millisecond)},
+	{Hosts: "*.cloudfront.net", Parallelism: 6, RandomDelay: Duration(100 * time.Millisecond)},
+	{Hosts: "*.amazonaws.com", Parallelism: 6, RandomDelay: Duration(100 * time.Millisecond)},
+	{Hosts: "youtube.com", Parallelism: 2, Delay: Duration(time.Second), RandomDelay: Duration(time.Second)},
+	{Hosts: "*.youtube.com", Parallelism: 2, Delay: Duration(time.Second), RandomDelay: Duration(time.Second)},
+	{Hosts: "*.googlevideo.com", Parallelism: 2, Delay: Duration(time.Second), RandomDelay: Duration(time.Second)},
+	{Hosts: "*", Parallelism: 4, RandomDelay: Duration(200 * time.Millisecond)},
+}
+
+// Matches determines if the host name matches the pattern of the limit.
+func (l HostLimit) Matches(host string) bool {
+	matched, err := path.Match(strings.ToLower(l.Hosts), strings.ToLower(host))
+	return err == nil && matched
+}
+
+// PoliteTransport limits the requests to each host with the first host limit matching the host. Hosts without a
+// matching limit are not limited. When robots.txt is respected, the requests the robots.txt of the host does not allow
+// for the User-Agent fail with ErrDisallowed.
+type PoliteTransport struct {
+	Limits []HostLimit
+	// RobotsTxt respects the robots.txt of the hosts.
+	RobotsTxt bool
+	// UserAgent is the agent the robots.txt rules are looked up for.
+	UserAgent string
+	// Transport makes the requests. The default transport is used when it is nil.
+	Transport http.RoundTripper
+
+	lock   sync.Mutex
+	hosts  map[string]*hostState
+	robots map[string]*robotsEntry
+}
+
+// hostState is the requests in progress to a host and when the next request may start.
+type hostState struct {
+	slots chan struct{}
+	next  time.Time
+}
+
+// robotsEntry is the robots.txt of a host. done is closed once it is loaded. err is why it could not be loaded.
+type robotsEntry struct {
+	done chan struct{}
+	data *robotstxt.RobotsData
+	err  error
+}
+
+// RoundTrip waits until the host of the request may be requested again and makes the request. The request counts
+// towards the parallelism of the host until the body of the response is closed.
+func (t *PoliteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.transport()
+	ctx := req.Context()
+	if t.RobotsTxt {
+		allowed, err := t.allowed(req)
+		if err != nil {
+			return nil, fmt.Errorf("request url: %s, %w", req.URL, err)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("request url: %s, %w", req.URL, ErrDisallowed)
+		}
+	}
+	limit, ok := t.limit(req.URL.Hostname())
+	if !ok {
+		return transport.RoundTrip(req)
+	}
+	state := t.host(req.URL.Host, limit)
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if state.slots != nil {
+			<-state.slots
+		}
+	}
+	if wait := t.reserve(state, limit); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (t *PoliteTransport) transport() http.RoundTripper {
+	if t.Transport == nil {
+		return http.DefaultTransport
+	}
+	return t.Transport
+}
+
+// limit returns the first limit matching the host.
+func (t *PoliteTransport) limit(host string) (HostLimit, bool) {
+	for _, limit := range t.Limits {
+		if limit.Matches(host) {
+			return limit, true
+		}
+	}
+	return HostLimit{}, false
+}
+
+// host returns the state of the host, creating it with the limit the first time the host is requested.
+func (t *PoliteTransport) host(host string, limit HostLimit) *hostState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.hosts == nil {
+		t.hosts = make(map[string]*hostState)
+	}
+	state, ok := t.hosts[host]
+	if !ok {
+		state = &hostState{}
+		if limit.Parallelism > 0 {
+			state.slots = make(chan struct{}, limit.Parallelism)
+		}
+		t.hosts[host] = state
+	}
+	return state
+}
+
+// reserve takes the next start time of the host and returns how long to wait for it.
+func (t *PoliteTransport) reserve(state *hostState, limit HostLimit) time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	start := state.next
+	if start.Before(now) {
+		start = now
+	}
+	delay := time.Duration(limit.Delay)
+	if limit.RandomDelay > 0 {
+		delay += time.Duration(rand.Int63n(int64(limit.RandomDelay) + 1))
+	}
+	state.next = start.Add(delay)
+	return start.Sub(now)
+}
+
+// allowed determines if the robots.txt of the host of the request allows the request. The robots.txt is requested
+// once per host. When it fails with a network error or a 5xx status code, the request fails so it can be retried, and
+// the robots.txt is requested again by the next request. Everything is allowed when it cannot be parsed.
+func (t *PoliteTransport) allowed(req *http.Request) (bool, error) {
+	key := req.URL.Scheme + "://" + req.URL.Host
+	t.lock.Lock()
+	if t.robots == nil {
+		t.robots = make(map[string]*robotsEntry)
+	}
+	entry, ok := t.robots[key]
+	if !ok {
+		entry = &robotsEntry{done: make(chan struct{})}
+		t.robots[key] = entry
+	}
+	t.lock.Unlock()
+	if !ok {
+		entry.data, entry.err = t.fetchRobots(req.Context(), key)
+		if entry.err != nil {
+			// The robots.txt is requested again by the next request instead of keeping the failure from now on
+			t.lock.Lock()
+			delete(t.robots, key)
+			t.lock.Unlock()
+		}
+		close(entry.done)
+	}
+	<-entry.done
+	if entry.err != nil {
+		return false, entry.err
+	}
+	if entry.data == nil {
+		return true, nil
+	}
+	agent := t.UserAgent
+	if len(agent) == 0 {
+		agent = "*"
+	}
+	return entry.data.TestAgent(req.URL.RequestURI(), agent), nil
+}
+
+// fetchRobots requests the robots.txt of the host. An error is returned when the robots.txt may load on the next try,
+// and no robots.txt when it cannot be parsed.
+func (t *PoliteTransport) fetchRobots(ctx context.Context, base string) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.UserAgent) > 0 {
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request robots.txt: %w", err)
+	}
+	defer io.CloseResource(resp.Body)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("failed to request robots.txt: status code %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read robots.txt: %w", err)
+	}
+	data, err := robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+	if err != nil {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// releaseBody releases the slot of the host of the request once the body is closed.
+type releaseBody struct {
+	goio.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close closes the body and releases the slot of the host.
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,167 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	spotlight "pet-spotlight/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimitMatches(t *testing.T) {
+	tests := []struct {
+		hosts    string
+		host     string
+		expected bool
+	}{
+		{"*", "www.petstablished.com", true},
+		{"*.petstablished.com", "www.petstablished.com", true},
+		{"*.petstablished.com", "petstablished.com", false},
+		{"*.cloudfront.net", "D1.CloudFront.net", true},
+		{"*.youtube.com", "i.ytimg.com", false},
+	}
+	for _, test := range tests {
+		if matches := (spotlight.HostLimit{Hosts: test.hosts}).Matches(test.host); matches != test.expected {
+			t.Errorf("%s matching %s is %t", test.hosts, test.host, matches)
+		}
+	}
+}
+
+func TestPoliteTransportParallelism(t *testing.T) {
+	var lock sync.Mutex
+	var active, longest int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		active++
+		if active > longest {
+			longest = active
+		}
+		lock.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		lock.Lock()
+		active--
+		lock.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &spotlight.PoliteTransport{Limits: []spotlight.HostLimit{{Hosts: "*", Parallelism: 2}}}}
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if longest > 2 {
+		t.Errorf("%d requests were made at the same time", longest)
+	}
+}
+
+func TestPoliteTransportDelay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &spotlight.PoliteTransport{Limits: []spotlight.HostLimit{{Hosts: "*", Delay: spotlight.Duration(50 * time.Millisecond)}}}}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// The first request is not delayed
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests took %s", elapsed)
+	}
+	// The delay is given up when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+}
+
+func TestPoliteTransportRobotsTxt(t *testing.T) {
+	requests := make(map[string]int)
+	var lock sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests[r.URL.Path]++
+		lock.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: shelter-bot\nDisallow: /private\n"))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	polite := &spotlight.PoliteTransport{RobotsTxt: true, UserAgent: "shelter-bot"}
+	client := &http.Client{Transport: &spotlight.RetryTransport{Policy: spotlight.RetryPolicy{Attempts: 3}, Transport: polite}}
+	// Disallowed requests are not retried
+	if _, err := client.Get(server.URL + "/private/dogs"); !errors.Is(err, spotlight.ErrDisallowed) {
+		t.Errorf("expected disallowed error, got %v", err)
+	}
+	if _, err := client.Get(server.URL + "/private/cats"); !errors.Is(err, spotlight.ErrDisallowed) {
+		t.Errorf("expected disallowed error, got %v", err)
+	}
+	// The rules of other agents do not apply
+	polite.UserAgent = "other-bot"
+	resp, err := client.Get(server.URL + "/private/dogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if requests["/robots.txt"] != 1 || requests["/private/dogs"] != 3 || requests["/private/cats"] != 0 {
+		t.Errorf("requests are %v", requests)
+	}
+}
+
+func TestPoliteTransportRobotsTxtUnavailable(t *testing.T) {
+	var robotsRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			// The robots.txt is unavailable the first time it is requested
+			if atomic.AddInt32(&robotsRequests, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &spotlight.PoliteTransport{RobotsTxt: true}}
+	// The failure is not kept, so the next request loads the robots.txt
+	if _, err := client.Get(server.URL + "/dogs"); err == nil || errors.Is(err, spotlight.ErrDisallowed) {
+		t.Errorf("expected robots.txt error, got %v", err)
+	}
+	resp, err := client.Get(server.URL + "/dogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, err = client.Get(server.URL + "/private/dogs"); !errors.Is(err, spotlight.ErrDisallowed) {
+		t.Errorf("expected disallowed error, got %v", err)
+	}
+	if robotsRequests != 2 {
+		t.Errorf("robots.txt was requested %d times", robotsRequests)
+	}
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	goio "io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"pet-spotlight/io"
+	"strconv"
+	"time"
+)
+
+// Defaults of the retry policy.
+const (
+	DefaultAttempts  = 3
+	DefaultBaseDelay = time.Second
+	DefaultMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy is how often and how long apart failed requests are retried. Requests are retried on network errors and
+// on responses with a 429 or 5xx status code. The delay doubles with each attempt, with jitter so that requests failing
+// together are not retried together. When a 429 or 503 response has a Retry-After header, the delay of the header is
+// used, unless it is longer than the max delay.
+type RetryPolicy struct {
+	// Attempts is the most times a request is made, including the first. Requests are not retried when it is 1 or
+	// less.
+	Attempts int
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay between two attempts. When the Retry-After header of the server asks for a longer
+	// delay, the request is not retried and the response is returned, so a download is not stalled for as long as the
+	// server wants. There is no longest delay when it is zero.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used when none is configured.
+var DefaultRetryPolicy = RetryPolicy{Attempts: DefaultAttempts, BaseDelay: DefaultBaseDelay, MaxDelay: DefaultMaxDelay}
+
+// Retry is a request that is about to be made again.
+type Retry struct {
+	URL string
+	// Attempt is the attempt that is about to be made, starting at 2 for the first retry.
+	Attempt  int
+	Attempts int
+	Delay    time.Duration
+	// Err is why the previous attempt failed.
+	Err error
+}
+
+// String describes the retry, e.g. "Retrying https://example.com/image.jpg in 2s (attempt 2 of 3): status code 503".
+func (r Retry) String() string {
+	return fmt.Sprintf("Retrying %s in %s (attempt %d of %d): %v", r.URL, r.Delay.Round(time.Millisecond), r.Attempt, r.Attempts, r.Err)
+}
+
+type retryReportKey struct{}
+
+// WithRetryReport returns a copy of the context that reports the retries of the requests made with it.
+func WithRetryReport(ctx context.Context, report func(Retry)) context.Context {
+	return context.WithValue(ctx, retryReportKey{}, report)
+}
+
+// WithRetry returns a copy of the client whose requests are retried with the policy. The default client is copied when
+// the client is nil.
+func WithRetry(client *http.Client, policy RetryPolicy) *http.Client {
+	retryClient := *orDefault(client)
+	retryClient.Transport = &RetryTransport{Policy: policy, Transport: retryClient.Transport}
+	return &retryClient
+}
+
+// RetryTransport retries the failed requests of the transport with the policy.
+type RetryTransport struct {
+	Policy RetryPolicy
+	// Transport makes the requests. The default transport is used when it is nil.
+	Transport http.RoundTripper
+}
+
+// RoundTrip makes the request until it succeeds, the attempts of the policy are used up or the context of the request
+// is done. The response of the last attempt is returned. Requests with a body that cannot be read again are not
+// retried, and neither are requests disallowed by robots.txt or asked to wait longer than the max delay.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(ctx)
+			if req.Body != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+		resp, err := transport.RoundTrip(attemptReq)
+		if attempt >= t.Policy.Attempts || ctx.Err() != nil || errors.Is(err, ErrDisallowed) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		var retryAfter time.Duration
+		if err == nil {
+			if !isRetryable(resp.StatusCode) {
+				return resp, nil
+			}
+			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+				retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+				if t.Policy.MaxDelay > 0 && retryAfter > t.Policy.MaxDelay {
+					return resp, nil
+				}
+			}
+			err = fmt.Errorf("status code %d", resp.StatusCode)
+			// The body is drained so the connection can be reused
+			goio.Copy(ioutil.Discard, resp.Body)
+			io.CloseResource(resp.Body)
+		}
+		delay := t.Policy.Delay(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		if report, ok := ctx.Value(retryReportKey{}).(func(Retry)); ok {
+			report(Retry{URL: req.URL.String(), Attempt: attempt + 1, Attempts: t.Policy.Attempts, Delay: delay, Err: err})
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Delay returns how long to wait after the failed attempt, starting at 1. The delay doubles with each attempt up to
+// the max delay, and a random half of it is taken off.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// isRetryable determines if a response with the status code is worth retrying.
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter returns the delay of the Retry-After header, which is either a number of seconds or a date. Zero is
+// returned when there is no delay.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	spotlight "pet-spotlight/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := spotlight.RetryPolicy{Attempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, longest := range expected {
+		for j := 0; j < 20; j++ {
+			// The jitter takes up to half of the delay off
+			if delay := policy.Delay(i + 1); delay < longest/2 || delay > longest {
+				t.Errorf("delay of attempt %d is %s, expected between %s and %s", i+1, delay, longest/2, longest)
+			}
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+	client := spotlight.WithRetry(server.Client(), spotlight.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+	var retries []spotlight.Retry
+	ctx := spotlight.WithRetryReport(context.Background(), func(r spotlight.Retry) {
+		retries = append(retries, r)
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests != 3 {
+		t.Errorf("status code is %d after %d requests", resp.StatusCode, requests)
+	}
+	if len(retries) != 2 {
+		t.Fatalf("retries are %+v", retries)
+	}
+	if retries[0].Attempt != 2 || retries[0].Attempts != 3 || !strings.Contains(retries[0].Err.Error(), "502") {
+		t.Errorf("first retry is %+v", retries[0])
+	}
+	// The delay asked for by the server is used over the policy
+	if retries[1].Attempt != 3 || retries[1].Delay != time.Second {
+		t.Errorf("second retry is %+v", retries[1])
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := spotlight.WithRetry(server.Client(), spotlight.RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// The response of the last attempt is returned
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 2 {
+		t.Errorf("status code is %d after %d requests", resp.StatusCode, requests)
+	}
+	// Client errors are not retried
+	resp, err = client.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || requests != 3 {
+		t.Errorf("status code is %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestRetryTransportRetryAfterTooLong(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := spotlight.WithRetry(server.Client(), spotlight.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// The server asks to wait longer than the max delay, so its response is returned without waiting
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Errorf("status code is %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestRetryTransportCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := spotlight.WithRetry(server.Client(), spotlight.RetryPolicy{Attempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	// Cancel while waiting for the first retry
+	ctx = spotlight.WithRetryReport(ctx, func(spotlight.Retry) {
+		cancel()
+	})
+	if _, err := spotlight.Download(ctx, client, server.URL, "", "image-0", spotlight.FormatOriginal, nil); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+}
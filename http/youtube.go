@@ -1,13 +1,13 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
 	"pet-spotlight/io"
 	"strings"
 )
@@ -33,39 +33,37 @@ const (
 	mp4720p itag = 22
 )
 
-// DownloadVideo downloads the Youtube video.
-func DownloadVideo(youtubeURL string, path string, fileName string) error {
-	downloadURL, err := getDownloadURL(youtubeURL)
+// DownloadVideo downloads the Youtube video with the client. The default client is used when the client is nil. The
+// download stops when the context is done, and the partially written file is removed. The progress of writing the file
+// is reported when the report is not nil.
+func DownloadVideo(ctx context.Context, client *http.Client, youtubeURL string, path string, fileName string, report func(io.Progress)) (File, error) {
+	client = orDefault(client)
+	downloadURL, err := getDownloadURL(ctx, client, youtubeURL)
 	if err != nil {
-		return err
+		return File{}, err
 	}
 	decodedDownloadURL, err := url.QueryUnescape(downloadURL)
 	if err != nil {
-		return err
+		return File{}, err
 	}
-	resp, err := http.Get(decodedDownloadURL)
+	resp, err := get(ctx, client, decodedDownloadURL)
 	if err != nil {
-		return err
+		return File{}, err
 	}
 	defer io.CloseResource(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("failed to download video")
+		return File{}, errors.New("failed to download video")
 	}
 	filePath := fmt.Sprintf("%s/%s", path, fileName)
-	f, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filePath, err)
-	}
-	defer io.CloseResource(f)
-	if err = io.CopyToFile(resp.Body, f); err != nil {
-		return err
+	if err = saveToFile(resp.Body, filePath, resp.ContentLength, report); err != nil {
+		return File{}, err
 	}
-	return err
+	return File{Path: filePath, SourceURL: youtubeURL, ContentType: resp.Header.Get("Content-Type")}, nil
 }
 
-func getDownloadURL(youtubeURL string) (string, error) {
+func getDownloadURL(ctx context.Context, client *http.Client, youtubeURL string) (string, error) {
 	u := fmt.Sprintf("http://youtube.com/get_video_info?video_id=%s", strings.Split(youtubeURL, "?v=")[1][:11])
-	resp, err := http.Get(u)
+	resp, err := get(ctx, client, u)
 	if err != nil {
 		return "", err
 	}
@@ -1,12 +1,18 @@
 package io
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
+// ProgressInterval is the shortest time between two reports of the progress of a copy.
+const ProgressInterval = 250 * time.Millisecond
+
 // MakeDir creates the specified directory.
 func MakeDir(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -25,6 +31,19 @@ func WriteFile(content string, file string) error {
 	return nil
 }
 
+// WriteFileIfChanged writes the string content to the specified file only when the file does not already have the
+// content. It returns whether the file was written.
+func WriteFileIfChanged(content string, file string) (bool, error) {
+	existing, err := ioutil.ReadFile(file)
+	if err == nil && string(existing) == content {
+		return false, nil
+	}
+	if err = WriteFile(content, file); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // CopyFile copies the content to the file.
 func CopyToFile(content io.Reader, w io.Writer) error {
 	if _, err := io.Copy(w, content); err != nil {
@@ -33,6 +52,95 @@ func CopyToFile(content io.Reader, w io.Writer) error {
 	return nil
 }
 
+// CopyToFileWithProgress copies the content to the file and reports the progress of the copy at most once per progress
+// interval, and once more when the copy is done. The total is the size of the content, or zero when it is not known.
+func CopyToFileWithProgress(content io.Reader, w io.Writer, total int64, report func(Progress)) error {
+	return CopyToFile(NewProgressReader(content, total, ProgressInterval, report), w)
+}
+
+// Progress is how much of the content has been copied.
+type Progress struct {
+	// Written is the number of bytes copied so far.
+	Written int64
+	// Total is the size of the content. It is zero when the size is not known.
+	Total int64
+	// Rate is the number of bytes copied per second.
+	Rate float64
+	// ETA is the time left until the content is copied. It is zero when the size is not known.
+	ETA time.Duration
+}
+
+// Fraction returns how much of the content has been copied, between 0 and 1. It is zero when the size is not known.
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Written) / float64(p.Total)
+}
+
+// ProgressReader reports how much of the reader has been read.
+type ProgressReader struct {
+	reader   io.Reader
+	total    int64
+	interval time.Duration
+	report   func(Progress)
+	read     int64
+	start    time.Time
+	last     time.Time
+}
+
+// NewProgressReader creates a reader that reports how much of the reader has been read at most once per interval, and
+// once more at the end of the reader. The total is the size of the reader, or zero when it is not known. Nothing is
+// reported when the report is nil.
+func NewProgressReader(r io.Reader, total int64, interval time.Duration, report func(Progress)) *ProgressReader {
+	if total < 0 {
+		total = 0
+	}
+	now := time.Now()
+	return &ProgressReader{reader: r, total: total, interval: interval, report: report, start: now, last: now}
+}
+
+// Read reads from the reader and reports the progress when the interval has passed since the last report.
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	p.read += int64(n)
+	if p.report == nil {
+		return n, err
+	}
+	now := time.Now()
+	if err == io.EOF || now.Sub(p.last) >= p.interval {
+		p.last = now
+		p.report(p.progress(now))
+	}
+	return n, err
+}
+
+func (p *ProgressReader) progress(now time.Time) Progress {
+	progress := Progress{Written: p.read, Total: p.total}
+	if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 {
+		progress.Rate = float64(p.read) / elapsed
+	}
+	if p.total > p.read && progress.Rate > 0 {
+		progress.ETA = time.Duration(float64(p.total-p.read) / progress.Rate * float64(time.Second))
+	}
+	return progress
+}
+
+// HashFile returns the size and the hex encoded SHA-256 hash of the file.
+func HashFile(file string) (int64, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open file %s: %w", file, err)
+	}
+	defer CloseResource(f)
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to hash file %s: %w", file, err)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // CloseResource closes the provided closer.
 func CloseResource(c io.Closer) {
 	if err := c.Close(); err != nil {
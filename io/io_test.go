@@ -7,6 +7,7 @@ import (
 	"os"
 	"pet-spotlight/io"
 	"testing"
+	"testing/iotest"
 )
 
 func TestWriteFile(t *testing.T) {
@@ -30,6 +31,30 @@ func TestWriteFile(t *testing.T) {
 	}
 }
 
+func TestWriteFileIfChanged(t *testing.T) {
+	f, err := ioutil.TempFile("", "write-file-if-changed-test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := f.Name()
+	defer os.Remove(fileName)
+	defer f.Close()
+	written, err := io.WriteFileIfChanged("this is a test", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !written {
+		t.Error("expected the file to be written")
+	}
+	written, err = io.WriteFileIfChanged("this is a test", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written {
+		t.Error("expected the unchanged file to not be written")
+	}
+}
+
 func TestCopyToFile(t *testing.T) {
 	var content bytes.Buffer
 	expectedContent := "this is a test"
@@ -46,6 +71,29 @@ func TestCopyToFile(t *testing.T) {
 	}
 }
 
+func TestHashFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "hash-file-test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := f.Name()
+	defer os.Remove(fileName)
+	defer f.Close()
+	if err = io.WriteFile("this is a test", fileName); err != nil {
+		t.Fatal(err)
+	}
+	size, hash, err := io.HashFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 14 {
+		t.Errorf("size of the file is %d", size)
+	}
+	if hash != "2e99758548972a8e8822ad47fa1017ff72f06f3ff6a016851f45c398732bc50c" {
+		t.Errorf("hash of the file is %s", hash)
+	}
+}
+
 type closerTester struct {
 }
 
@@ -57,3 +105,45 @@ func TestCloseResource(t *testing.T) {
 	tester := closerTester{}
 	io.CloseResource(tester)
 }
+
+func TestProgressReader(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 100)
+	var reports []io.Progress
+	r := io.NewProgressReader(iotest.OneByteReader(bytes.NewReader(content)), int64(len(content)), 0, func(p io.Progress) {
+		reports = append(reports, p)
+	})
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	// Every read is reported without an interval, including the end of the content
+	if len(reports) != 101 {
+		t.Fatalf("reported %d times", len(reports))
+	}
+	if reports[49].Written != 50 || reports[49].Fraction() != 0.5 || reports[49].Rate <= 0 || reports[49].ETA <= 0 {
+		t.Errorf("report halfway is %+v", reports[49])
+	}
+	last := reports[len(reports)-1]
+	if last.Written != 100 || last.Total != 100 || last.ETA != 0 {
+		t.Errorf("last report is %+v", last)
+	}
+}
+
+func TestCopyToFileWithProgress(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 1000)
+	var reports []io.Progress
+	var w bytes.Buffer
+	// The size is not known
+	err := io.CopyToFileWithProgress(iotest.OneByteReader(bytes.NewReader(content)), &w, -1, func(p io.Progress) {
+		reports = append(reports, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Len() != len(content) {
+		t.Errorf("copied %d bytes", w.Len())
+	}
+	// The reports are throttled, the copy is done well within the interval so only the end is reported
+	if len(reports) != 1 || reports[0].Written != 1000 || reports[0].Total != 0 || reports[0].Fraction() != 0 {
+		t.Errorf("reports are %+v", reports)
+	}
+}
@@ -2,26 +2,70 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"fyne.io/fyne"
 	"fyne.io/fyne/app"
+	"fyne.io/fyne/layout"
 	"fyne.io/fyne/widget"
 	"os"
+	"path/filepath"
+	"pet-spotlight/description"
+	"pet-spotlight/dog"
+	"pet-spotlight/export"
+	"pet-spotlight/http"
 	"pet-spotlight/io"
+	"pet-spotlight/organization"
+	"pet-spotlight/photo"
+	"pet-spotlight/profile"
+	"pet-spotlight/progress"
+	"pet-spotlight/social"
+	"pet-spotlight/source"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 type flags struct {
-	dogs             string
-	baseDirectory    string
-	determineFosters bool
+	dogs               string
+	baseDirectory      string
+	determineFosters   bool
+	organization       string
+	organizationsFile  string
+	profileFile        string
+	exportFile         string
+	refresh            bool
+	imageFormat        string
+	presets            string
+	watermarkFile      string
+	collage            string
+	collageDirectory   string
+	template           string
+	templatesDirectory string
+	platforms          string
+	matchThreshold     float64
+	strict             bool
+	attempts           int
+	clientFile         string
+	robots             bool
+	offline            bool
 }
 
 func main() {
+	// Run headless when a command is provided
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
 	// Create app
 	mainApp := app.New()
-	// Create quit button
+	// The download in progress, replaced each time a download starts
+	inProgress := newActiveDownload()
+	// Create quit button, the download in progress is stopped before quitting so no files are left half written
 	quitButton := widget.NewButton("Quit", func() {
+		inProgress.stop()
 		mainApp.Quit()
 	})
 	// Create error window
@@ -46,6 +90,65 @@ func main() {
 		errorWindow.Show()
 		return
 	}
+	// Load the organizations to pick from
+	orgs, err := organization.LoadOrDefault(organization.DefaultFile)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	// Load the selectors used to scrape the pages
+	scrapeProfile, err := profile.LoadOrDefault(profile.DefaultFile)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	// Load the social media sizes
+	presets, err := photo.LoadPresetsOrDefault(photo.DefaultPresetsFile)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	// Load the description templates
+	templates, err := description.LoadOrDefault(description.DefaultDirectory)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	// Load the social media platforms
+	platforms, err := social.LoadPlatformsOrDefault(social.DefaultPlatformsFile)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	// Load the settings of the client that makes all the requests
+	clientConfig, err := http.LoadClientConfigOrDefault(http.DefaultClientFile)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	client, err := http.NewClient(clientConfig, http.DefaultRetryPolicy)
+	if err != nil {
+		errorEntry.SetText(fmt.Sprintf("%+v", err))
+		errorWindow.Show()
+		return
+	}
+	selectedOrg := orgs[0]
+	orgNames := make([]string, len(orgs))
+	for i, org := range orgs {
+		orgNames[i] = org.String()
+	}
+	orgSelect := widget.NewSelect(orgNames, func(name string) {
+		if org, ok := organization.Find(orgs, name); ok {
+			selectedOrg = org
+		}
+	})
+	orgSelect.SetSelected(selectedOrg.String())
 	// Create main window
 	mainWindow := mainApp.NewWindow("Pet Spotlight")
 	// Create directory entry
@@ -53,6 +156,56 @@ func main() {
 	baseDirectoryEntry.SetText(dir)
 	// Create the dog entry
 	dogEntry := widget.NewEntry()
+	// Create the refresh check
+	refreshCheck := widget.NewCheck("Only download new or changed files", nil)
+	// Create the image format select
+	imageFormats := []string{"Original"}
+	for _, format := range http.ImageFormats {
+		imageFormats = append(imageFormats, strings.ToUpper(format))
+	}
+	imageFormatSelect := widget.NewSelect(imageFormats, nil)
+	imageFormatSelect.SetSelected(imageFormats[0])
+	// Create the description template select
+	templateSelect := widget.NewSelect(templates.Names(), nil)
+	templateSelect.SetSelected(description.Adoption)
+	// Create the social media check
+	presetsCheck := widget.NewCheck("Create social media sizes", nil)
+	// Create the watermark check
+	watermarkCheck := widget.NewCheck("Create watermarked copies", nil)
+	// Create the collage check
+	collageCheck := widget.NewCheck("Create collage", nil)
+	// Create the social posts check
+	postsCheck := widget.NewCheck("Compose social media posts", nil)
+	// Create the attempts select, failed requests are retried until the attempts are used up
+	policy := http.DefaultRetryPolicy
+	rebuildClient := func() {
+		newClient, err := http.NewClient(clientConfig, policy)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		client = newClient
+	}
+	attemptOptions := []string{"1", "2", "3", "4", "5"}
+	attemptsSelect := widget.NewSelect(attemptOptions, func(selected string) {
+		if attempts, err := strconv.Atoi(selected); err == nil {
+			policy.Attempts = attempts
+		}
+		rebuildClient()
+	})
+	attemptsSelect.SetSelected(strconv.Itoa(http.DefaultAttempts))
+	// Create the robots.txt check, the pages and files the sites ask not to be requested are skipped
+	robotsCheck := widget.NewCheck("Respect robots.txt", func(checked bool) {
+		clientConfig.RobotsTxt = checked
+		rebuildClient()
+	})
+	robotsCheck.SetChecked(clientConfig.RobotsTxt)
+	// Create the offline check, only the cached pages are used so nothing is requested
+	offlineCheck := widget.NewCheck("Only use cached pages", func(checked bool) {
+		clientConfig.Cache.Offline = checked
+		rebuildClient()
+	})
+	offlineCheck.SetChecked(clientConfig.Cache.Offline)
 	// Create progress bar
 	progressBar := widget.NewProgressBarInfinite()
 	progressBar.Stop()
@@ -60,13 +213,61 @@ func main() {
 	// Create download button
 	downloadWindow := mainApp.NewWindow("Download Progress")
 	downloadEntry := widget.NewMultiLineEntry()
+	downloadBar := widget.NewProgressBar()
+	transfersEntry := widget.NewMultiLineEntry()
 	downloadCloseButton := widget.NewButton("Close", func() {
 		downloadEntry.SetText("")
 		downloadWindow.Hide()
 	})
-	downloadWindow.SetContent(widget.NewVBox(downloadEntry, downloadCloseButton))
-	downloadButton := widget.NewButton("Download", func() {
-		progressChannel := make(chan string, 10)
+	downloadCancelButton := widget.NewButton("Cancel", func() {
+		inProgress.cancelDownload()
+	})
+	downloadCancelButton.Disable()
+	// The content is laid out again whenever a dog is added to the table
+	setDownloadContent := func(table *statusTable) {
+		downloadWindow.SetContent(widget.NewVBox(downloadBar, table.container, transfersEntry, downloadEntry, widget.NewHBox(downloadCancelButton, downloadCloseButton)))
+	}
+	setDownloadContent(newStatusTable())
+	var downloadButton *widget.Button
+	downloadButton = widget.NewButton("Download", func() {
+		imageFormat, err := http.ParseImageFormat(imageFormatSelect.Selected)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		opts := DownloadOptions{Refresh: refreshCheck.Checked, ImageFormat: imageFormat, Client: client}
+		if opts.Template, err = templates.Get(templateSelect.Selected); err != nil {
+			errorChannel <- err
+			return
+		}
+		if presetsCheck.Checked {
+			opts.Presets = presets
+		}
+		if watermarkCheck.Checked {
+			watermark, err := photo.LoadWatermark(photo.DefaultWatermarkFile)
+			if err != nil {
+				errorChannel <- err
+				return
+			}
+			opts.Watermark = &watermark
+		}
+		if postsCheck.Checked {
+			opts.Platforms = platforms
+		}
+		if collageCheck.Checked {
+			collage, err := photo.LoadCollageOrDefault(photo.DefaultCollageFile)
+			if err != nil {
+				errorChannel <- err
+				return
+			}
+			opts.Collage = &collage
+		}
+		src, err := source.New(selectedOrg, scrapeProfile, client)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		progressChannel := make(chan progress.Event, 10)
 		// Create directory where the dog info will go
 		if err := io.MakeDir(baseDirectoryEntry.Text); err != nil {
 			errorEntry := widget.NewEntry()
@@ -74,29 +275,73 @@ func main() {
 			errorWindow.SetContent(widget.NewVBox(errorEntry, errorCloseButton))
 			errorWindow.Show()
 		}
-		progressBar.Start()
-		progressBar.Show()
+		// The download can be canceled until it has stopped
+		ctx, cancel := context.WithCancel(context.Background())
+		done := inProgress.start(cancel)
+		opts.Disambiguate = disambiguator(ctx, mainApp)
+		tracker := progress.NewTracker()
+		table := newStatusTable()
+		downloadBar.SetValue(0)
+		transfersEntry.SetText("")
+		setDownloadContent(table)
+		downloadButton.Disable()
+		downloadCancelButton.Enable()
 		downloadWindow.Show()
 		go func() {
-			if err := RunDogDownloads(dogEntry.Text, baseDirectoryEntry.Text, progressChannel, errorChannel); err != nil {
+			defer close(done)
+			defer cancel()
+			if _, err := RunDogDownloads(ctx, src, dogEntry.Text, baseDirectoryEntry.Text, opts, progressChannel, errorChannel); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, ErrMissing) {
+				// The missing dogs are already listed with the progress
 				errorEntry := widget.NewEntry()
 				errorEntry.SetText(fmt.Sprintf("%+v", err))
 				errorWindow.SetContent(widget.NewVBox(errorEntry, errorCloseButton))
 				errorWindow.Show()
 			}
+			downloadCancelButton.Disable()
+			downloadButton.Enable()
 		}()
 		downloadEntry.SetText("Downloading...\n")
-		for progress := range progressChannel {
-			downloadEntry.SetText(downloadEntry.Text + progress + "\n")
-		}
-		progressBar.Stop()
-		progressBar.Hide()
+		// Show the progress without blocking the UI, so the dog to download can be picked when a name is ambiguous
+		go func() {
+			for event := range progressChannel {
+				tracker.Update(event)
+				if len(event.Message) > 0 {
+					downloadEntry.SetText(downloadEntry.Text + event.Message + "\n")
+				}
+				if table.update(tracker.Statuses()) {
+					setDownloadContent(table)
+				}
+				downloadBar.SetValue(tracker.Fraction())
+				transfersEntry.SetText(joinTransfers(tracker.Transfers()))
+			}
+		}()
 	})
 	// Create foster window
 	boardingDogsWindow := mainApp.NewWindow("Boarding Dogs")
 	boardingCloseButton := widget.NewButton("Close", func() {
 		boardingDogsWindow.Hide()
 	})
+	var boardingList []dog.Dog
+	exportFormats := make([]string, len(export.Formats))
+	for i, format := range export.Formats {
+		exportFormats[i] = strings.ToUpper(string(format))
+	}
+	exportSelect := widget.NewSelect(exportFormats, nil)
+	exportSelect.SetSelected(exportFormats[0])
+	exportLabel := widget.NewLabel("")
+	exportButton := widget.NewButton("Export", func() {
+		format, err := export.ParseFormat(exportSelect.Selected)
+		if err != nil {
+			errorChannel <- err
+			return
+		}
+		exportFile := filepath.Join(baseDirectoryEntry.Text, "boarding-list."+string(format))
+		if err = export.ToFile(exportFile, boardingList); err != nil {
+			errorChannel <- err
+			return
+		}
+		exportLabel.SetText("Exported to " + exportFile)
+	})
 	// Set the window content
 	mainWindow.SetContent(widget.NewVBox(
 		// Lookup fosters group
@@ -106,16 +351,18 @@ func main() {
 			downloadButton.Disable()
 			baseDirectoryEntry.Disable()
 			dogEntry.Disable()
-			fosters, err := RunGetFosters(errorChannel)
+			fosters, err := RunGetBoardingList(context.Background(), orgs, scrapeProfile, client, errorChannel)
 			if err != nil {
 				errorEntry := widget.NewEntry()
 				errorEntry.SetText(fmt.Sprintf("%+v", err))
 				errorWindow.SetContent(widget.NewVBox(errorEntry, errorCloseButton))
 				errorWindow.Show()
 			}
+			boardingList = fosters
 			dogs := widget.NewMultiLineEntry()
 			dogs.SetText(joinDogs(fosters))
-			boardingDogsWindow.SetContent(widget.NewVBox(dogs, boardingCloseButton))
+			exportLabel.SetText("")
+			boardingDogsWindow.SetContent(widget.NewVBox(dogs, widget.NewHBox(exportSelect, exportButton), exportLabel, boardingCloseButton))
 			progressBar.Stop()
 			progressBar.Hide()
 			downloadButton.Enable()
@@ -125,11 +372,44 @@ func main() {
 		})),
 		// Download dogs group
 		widget.NewGroup("Dog Download", widget.NewForm(&widget.FormItem{
+			Text:   "Organization:",
+			Widget: orgSelect,
+		}, &widget.FormItem{
 			Text:   "Output Directory:",
 			Widget: baseDirectoryEntry,
 		}, &widget.FormItem{
 			Text:   "Dogs (comma separated):",
 			Widget: dogEntry,
+		}, &widget.FormItem{
+			Text:   "Description:",
+			Widget: templateSelect,
+		}, &widget.FormItem{
+			Text:   "Image Format:",
+			Widget: imageFormatSelect,
+		}, &widget.FormItem{
+			Text:   "Social Media:",
+			Widget: presetsCheck,
+		}, &widget.FormItem{
+			Text:   "Watermark:",
+			Widget: watermarkCheck,
+		}, &widget.FormItem{
+			Text:   "Collage:",
+			Widget: collageCheck,
+		}, &widget.FormItem{
+			Text:   "Social Posts:",
+			Widget: postsCheck,
+		}, &widget.FormItem{
+			Text:   "Refresh:",
+			Widget: refreshCheck,
+		}, &widget.FormItem{
+			Text:   "Attempts:",
+			Widget: attemptsSelect,
+		}, &widget.FormItem{
+			Text:   "Robots:",
+			Widget: robotsCheck,
+		}, &widget.FormItem{
+			Text:   "Offline:",
+			Widget: offlineCheck,
 		}), downloadButton),
 		progressBar,
 		// Quit
@@ -140,20 +420,185 @@ func main() {
 	close(errorChannel)
 }
 
-func joinDogs(fosters []string) string {
-	sort.Sort(sort.StringSlice(fosters))
-	return insertNewLine(strings.Join(fosters, ","), 100)
+func joinDogs(fosters []dog.Dog) string {
+	names := make([]string, len(fosters))
+	for i, foster := range fosters {
+		names[i] = fmt.Sprintf("%s (%s)", foster.Name, foster.Organization)
+	}
+	sort.Sort(sort.StringSlice(names))
+	return wrapNames(names, 100)
 }
 
-func insertNewLine(input string, index int) string {
+// joinTransfers lists the files being downloaded, one per line.
+func joinTransfers(transfers []progress.Transfer) string {
+	lines := make([]string, len(transfers))
+	for i, transfer := range transfers {
+		lines[i] = transfer.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapNames joins the names with commas, starting a new line before a name that would make the line longer than the
+// width. A name is never split, so a name longer than the width has a line of its own.
+func wrapNames(names []string, width int) string {
 	var buffer bytes.Buffer
-	var currentPos = index - 1
-	var lastIndex = len(input) - 1
-	for i, r := range input {
-		buffer.WriteRune(r)
-		if i%index == currentPos && i != lastIndex {
-			buffer.WriteRune('\n')
+	lineLength := 0
+	for i, name := range names {
+		nameLength := utf8.RuneCountInString(name)
+		if i > 0 {
+			buffer.WriteRune(',')
+			lineLength++
+			if lineLength+nameLength > width {
+				buffer.WriteRune('\n')
+				lineLength = 0
+			}
 		}
+		buffer.WriteString(name)
+		lineLength += nameLength
 	}
 	return buffer.String()
 }
+
+// disambiguator asks in a window which of the listings to download when a dog matches more than one listing. Closing
+// the window skips the dog. The window is closed when the context is done.
+func disambiguator(ctx context.Context, mainApp fyne.App) Disambiguator {
+	return func(query string, candidates []dog.Dog) (int, error) {
+		choice := make(chan int, 1)
+		choose := func(index int) {
+			select {
+			case choice <- index:
+			default:
+			}
+		}
+		options := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			options[i] = fmt.Sprintf("%s (%s)", candidate.Name, candidate.URL)
+		}
+		radio := widget.NewRadio(options, nil)
+		radio.SetSelected(options[0])
+		window := mainApp.NewWindow("Which " + query + "?")
+		window.SetOnClosed(func() {
+			choose(-1)
+		})
+		downloadButton := widget.NewButton("Download", func() {
+			for i, option := range options {
+				if option == radio.Selected {
+					choose(i)
+				}
+			}
+			window.Close()
+		})
+		skipButton := widget.NewButton("Skip", func() {
+			choose(-1)
+			window.Close()
+		})
+		label := widget.NewLabel(fmt.Sprintf("'%s' matches more than one dog. Which one should be downloaded?", query))
+		window.SetContent(widget.NewVBox(label, radio, widget.NewHBox(downloadButton, skipButton)))
+		window.Show()
+		select {
+		case index := <-choice:
+			return index, nil
+		case <-ctx.Done():
+			window.Close()
+			return -1, ctx.Err()
+		}
+	}
+}
+
+// statusColumns are the headers of the columns of the status table.
+var statusColumns = []string{"Dog", "Status", "Files", "Downloaded"}
+
+// statusTable shows the phase, the files and the bytes downloaded of each dog, one row per dog.
+type statusTable struct {
+	container *fyne.Container
+	rows      map[string][]*widget.Label
+}
+
+func newStatusTable() *statusTable {
+	t := &statusTable{rows: make(map[string][]*widget.Label)}
+	t.container = fyne.NewContainerWithLayout(layout.NewGridLayout(len(statusColumns)), t.headers()...)
+	return t
+}
+
+func (t *statusTable) headers() []fyne.CanvasObject {
+	headers := make([]fyne.CanvasObject, len(statusColumns))
+	for i, column := range statusColumns {
+		headers[i] = widget.NewLabelWithStyle(column, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	return headers
+}
+
+// update shows the statuses in the table. It returns whether a row was added, in which case the table has grown and
+// the window must be laid out again.
+func (t *statusTable) update(statuses []progress.Status) bool {
+	added := false
+	for _, s := range statuses {
+		row, ok := t.rows[s.Dog]
+		if !ok {
+			row = make([]*widget.Label, len(statusColumns))
+			for i := range row {
+				row[i] = widget.NewLabel("")
+			}
+			t.rows[s.Dog] = row
+			added = true
+		}
+		row[0].SetText(s.Dog)
+		row[1].SetText(string(s.Phase))
+		row[2].SetText(fmt.Sprintf("%d/%d", s.FilesDone, s.Files))
+		row[3].SetText(progress.FormatBytes(s.BytesDone))
+	}
+	if added {
+		// The statuses are sorted by dog, so the rows are too
+		objects := t.headers()
+		for _, s := range statuses {
+			for _, label := range t.rows[s.Dog] {
+				objects = append(objects, label)
+			}
+		}
+		t.container.Objects = objects
+		t.container.Refresh()
+	}
+	return added
+}
+
+// activeDownload is the download in progress. The download is started from the UI, while the quit and cancel buttons
+// stop it from other goroutines.
+type activeDownload struct {
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newActiveDownload creates the state without a download in progress.
+func newActiveDownload() *activeDownload {
+	done := make(chan struct{})
+	close(done)
+	return &activeDownload{cancel: func() {}, done: done}
+}
+
+// start makes the download canceled with cancel the download in progress. The returned channel must be closed once
+// the download has stopped.
+func (a *activeDownload) start(cancel context.CancelFunc) chan struct{} {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.cancel = cancel
+	a.done = make(chan struct{})
+	return a.done
+}
+
+// cancelDownload cancels the download in progress without waiting for it to stop.
+func (a *activeDownload) cancelDownload() {
+	a.lock.Lock()
+	cancel := a.cancel
+	a.lock.Unlock()
+	cancel()
+}
+
+// stop cancels the download in progress and waits for it to stop.
+func (a *activeDownload) stop() {
+	a.lock.Lock()
+	cancel, done := a.cancel, a.done
+	a.lock.Unlock()
+	cancel()
+	<-done
+}
@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestWrapNames(t *testing.T) {
+	names := []string{"Bella (2 Blondes)", "Zoë (2 Blondes)", "Maximilian (2 Blondes)"}
+	// Lines are only broken after a comma, so no name is split
+	if wrapped := wrapNames(names, 34); wrapped != "Bella (2 Blondes),Zoë (2 Blondes),\nMaximilian (2 Blondes)" {
+		t.Errorf("wrapped is %q", wrapped)
+	}
+	// A name longer than the width has a line of its own
+	if wrapped := wrapNames(names, 10); wrapped != "Bella (2 Blondes),\nZoë (2 Blondes),\nMaximilian (2 Blondes)" {
+		t.Errorf("wrapped is %q", wrapped)
+	}
+}
@@ -0,0 +1,147 @@
+package match
+
+import (
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultThreshold is the lowest confidence a name is considered a match at.
+const DefaultThreshold = 0.75
+
+// Normalize folds the name so that names that only differ by case, accents or punctuation are equal. For example,
+// "Zoë's" becomes "zoes".
+func Normalize(name string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, name)
+	if err != nil {
+		folded = name
+	}
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(folded) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteRune(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_' || r == '/':
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Confidence of the matches that contain the query. They are below an exact match, so "max" prefers "Max" over
+// "Max (bonded with Milo)" over "Maxine".
+const (
+	wholeWordConfidence = 0.95
+	containedConfidence = 0.9
+)
+
+// Confidence returns how confident it is that the name of a listing is the dog that was asked for, from 0 to 1. Once
+// both are normalized, a listing that is the query is a certain match, followed by a listing that contains the query
+// as whole words and then a listing that contains the query anywhere. Otherwise the query is compared to the words of
+// the listing by edit distance, so typos such as "bello" still match "Bella".
+func Confidence(query string, name string) float64 {
+	query = Normalize(query)
+	name = Normalize(name)
+	if len(query) == 0 || len(name) == 0 {
+		return 0
+	}
+	if name == query {
+		return 1
+	}
+	if strings.Contains(" "+name+" ", " "+query+" ") {
+		return wholeWordConfidence
+	}
+	if strings.Contains(name, query) {
+		return containedConfidence
+	}
+	queryWords := len(strings.Fields(query))
+	nameWords := strings.Fields(name)
+	best := Similarity(query, name)
+	for i := range nameWords {
+		end := i + queryWords
+		if end > len(nameWords) {
+			break
+		}
+		if similarity := Similarity(query, strings.Join(nameWords[i:end], " ")); similarity > best {
+			best = similarity
+		}
+	}
+	return best
+}
+
+// Candidate is a name that matched a query.
+type Candidate struct {
+	// Index of the name in the names that were matched.
+	Index      int
+	Name       string
+	Confidence float64
+}
+
+// Candidates returns the names that match the query with a confidence of at least the threshold, the most confident
+// first.
+func Candidates(query string, names []string, threshold float64) []Candidate {
+	var candidates []Candidate
+	for i, name := range names {
+		if confidence := Confidence(query, name); confidence >= threshold {
+			candidates = append(candidates, Candidate{Index: i, Name: name, Confidence: confidence})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates
+}
+
+// Similarity returns one minus the edit distance between the strings relative to the length of the longest string.
+func Similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Distance(a, b))/float64(longest)
+}
+
+// Distance returns the Levenshtein edit distance between the strings.
+func Distance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minimum(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
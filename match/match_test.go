@@ -0,0 +1,67 @@
+package match_test
+
+import (
+	"pet-spotlight/match"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Zoë's":            "zoes",
+		"  BELLA  ":        "bella",
+		"\"Max\" (bonded)": "max bonded",
+		"Mary-Kate":        "mary kate",
+	}
+	for name, expected := range tests {
+		if normalized := match.Normalize(name); normalized != expected {
+			t.Errorf("%q normalized to %q, expected %q", name, normalized, expected)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	if confidence := match.Confidence("max", "Max"); confidence != 1 {
+		t.Errorf("exact name has confidence %.2f", confidence)
+	}
+	// An exact name is preferred over a name containing the query as a word, which is preferred over a longer name
+	word := match.Confidence("bella", "Bella (bonded with Max)")
+	contained := match.Confidence("max", "Maxine")
+	if word >= 1 || contained >= word || contained < match.DefaultThreshold {
+		t.Errorf("word has confidence %.2f and contained name %.2f", word, contained)
+	}
+	if confidence := match.Confidence("zoes", "Zoë's"); confidence != 1 {
+		t.Errorf("accented name has confidence %.2f", confidence)
+	}
+	if confidence := match.Confidence("bello", "Bella"); confidence < match.DefaultThreshold {
+		t.Errorf("typo has confidence %.2f", confidence)
+	}
+	if confidence := match.Confidence("bello", "Sir Bella Rose"); confidence < match.DefaultThreshold {
+		t.Errorf("typo in a longer name has confidence %.2f", confidence)
+	}
+	if confidence := match.Confidence("rex", "Bella"); confidence >= match.DefaultThreshold {
+		t.Errorf("different name has confidence %.2f", confidence)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if distance := match.Distance("kitten", "sitting"); distance != 3 {
+		t.Errorf("distance is %d", distance)
+	}
+	if distance := match.Distance("", "max"); distance != 3 {
+		t.Errorf("distance is %d", distance)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	names := []string{"Maxine", "Bella", "Max"}
+	candidates := match.Candidates("max", names, match.DefaultThreshold)
+	if len(candidates) != 2 {
+		t.Fatalf("found %d candidates", len(candidates))
+	}
+	if candidates[0].Name != "Max" || candidates[0].Index != 2 || candidates[1].Name != "Maxine" {
+		t.Errorf("candidates are %+v", candidates)
+	}
+	if candidates = match.Candidates("rex", names, match.DefaultThreshold); len(candidates) != 0 {
+		t.Errorf("candidates are %+v", candidates)
+	}
+}
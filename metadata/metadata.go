@@ -0,0 +1,189 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the metadata file written to the directory of each dog.
+const FileName = "metadata.json"
+
+// Metadata is the record of where the downloads of a dog came from.
+type Metadata struct {
+	Name         string    `json:"name"`
+	Query        string    `json:"query"`
+	Confidence   float64   `json:"confidence"`
+	Organization string    `json:"organization"`
+	URL          string    `json:"url"`
+	ScrapedAt    time.Time `json:"scrapedAt"`
+	Media        []Media   `json:"media"`
+}
+
+// Media is a downloaded image or video.
+type Media struct {
+	File        string `json:"file"`
+	SourceURL   string `json:"sourceURL"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	SHA256      string `json:"sha256"`
+}
+
+// Find returns the media that was downloaded from the source URL.
+func (m Metadata) Find(sourceURL string) (Media, bool) {
+	for _, media := range m.Media {
+		if media.SourceURL == sourceURL {
+			return media, true
+		}
+	}
+	return Media{}, false
+}
+
+// IsUnchanged determines if the file of the media in the directory still exists and has the recorded hash.
+func (m Media) IsUnchanged(directory string) bool {
+	if len(m.SHA256) == 0 {
+		return false
+	}
+	_, hash, err := io.HashFile(filepath.Join(directory, m.File))
+	return err == nil && hash == m.SHA256
+}
+
+// Describe creates the media record of the downloaded file. The dimensions are only set when the file is an image.
+func Describe(file string, sourceURL string, contentType string) (Media, error) {
+	size, hash, err := io.HashFile(file)
+	if err != nil {
+		return Media{}, err
+	}
+	media := Media{
+		File:        filepath.Base(file),
+		SourceURL:   sourceURL,
+		Size:        size,
+		ContentType: contentType,
+		SHA256:      hash,
+	}
+	media.Width, media.Height = dimensions(file)
+	return media, nil
+}
+
+func dimensions(file string) (int, int) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, 0
+	}
+	defer io.CloseResource(f)
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+// Write writes the metadata to the metadata file in the directory. The file is replaced in a single step so an
+// interrupted write never leaves a corrupt file behind.
+func Write(directory string, m Metadata) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata of %s: %w", m.Name, err)
+	}
+	file := filepath.Join(directory, FileName)
+	tmpFile := file + ".tmp"
+	if err = io.WriteFile(string(b), tmpFile); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile, file); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", tmpFile, file, err)
+	}
+	return nil
+}
+
+// Read reads the metadata file in the directory.
+func Read(directory string) (Metadata, error) {
+	file := filepath.Join(directory, FileName)
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to read metadata from %s: %w", file, err)
+	}
+	var m Metadata
+	if err = json.Unmarshal(b, &m); err != nil {
+		return Metadata{}, fmt.Errorf("failed to parse metadata from %s: %w", file, err)
+	}
+	return m, nil
+}
+
+// Manifest is the metadata of a dog that is written to the directory of the dog each time media is added. When a run
+// is interrupted, the manifest has all the media that completed. It is safe for concurrent use.
+type Manifest struct {
+	m         sync.Mutex
+	directory string
+	metadata  Metadata
+}
+
+// NewManifest creates the manifest of the metadata in the directory.
+func NewManifest(directory string, m Metadata) *Manifest {
+	return &Manifest{directory: directory, metadata: m}
+}
+
+// Add adds the media to the manifest and writes the manifest. Media with the same file or the same source URL
+// replaces the existing media.
+func (m *Manifest) Add(media Media) error {
+	m.m.Lock()
+	defer m.m.Unlock()
+	kept := m.metadata.Media[:0]
+	for _, existing := range m.metadata.Media {
+		if existing.File != media.File && existing.SourceURL != media.SourceURL {
+			kept = append(kept, existing)
+		}
+	}
+	m.metadata.Media = append(kept, media)
+	sort.Slice(m.metadata.Media, func(i, j int) bool {
+		return m.metadata.Media[i].File < m.metadata.Media[j].File
+	})
+	return Write(m.directory, m.metadata)
+}
+
+// Retain removes the media that is not from one of the source URLs from the manifest and writes the manifest.
+func (m *Manifest) Retain(sourceURLs []string) error {
+	m.m.Lock()
+	defer m.m.Unlock()
+	retained := make(map[string]bool, len(sourceURLs))
+	for _, u := range sourceURLs {
+		retained[u] = true
+	}
+	kept := m.metadata.Media[:0]
+	for _, existing := range m.metadata.Media {
+		if retained[existing.SourceURL] {
+			kept = append(kept, existing)
+		}
+	}
+	m.metadata.Media = kept
+	return Write(m.directory, m.metadata)
+}
+
+// Save writes the manifest.
+func (m *Manifest) Save() error {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return Write(m.directory, m.metadata)
+}
+
+// Get returns a copy of the metadata of the manifest.
+func (m *Manifest) Get() Metadata {
+	m.m.Lock()
+	defer m.m.Unlock()
+	metadata := m.metadata
+	metadata.Media = append([]Media(nil), m.metadata.Media...)
+	return metadata
+}
@@ -0,0 +1,132 @@
+package metadata_test
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/metadata"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "image-0.png")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	media, err := metadata.Describe(file, "https://example.com/image.png", "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.File != "image-0.png" {
+		t.Errorf("file is %s", media.File)
+	}
+	if media.Width != 4 || media.Height != 3 {
+		t.Errorf("dimensions are %dx%d", media.Width, media.Height)
+	}
+	if media.Size == 0 || len(media.SHA256) != 64 {
+		t.Errorf("size is %d and hash is %s", media.Size, media.SHA256)
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	expected := metadata.Metadata{
+		Name:      "Bella",
+		Query:     "bella",
+		URL:       "https://example.com/bella",
+		ScrapedAt: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC),
+		Media:     []metadata.Media{{File: "video-0.mp4", SourceURL: "https://youtube.com/watch?v=abc", Size: 10}},
+	}
+	if err = metadata.Write(dir, expected); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := metadata.Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Name != expected.Name || !actual.ScrapedAt.Equal(expected.ScrapedAt) || len(actual.Media) != 1 {
+		t.Errorf("read %+v", actual)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest := metadata.NewManifest(dir, metadata.Metadata{Name: "Bella"})
+	if err = manifest.Add(metadata.Media{File: "image-1.png", SourceURL: "https://example.com/1.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = manifest.Add(metadata.Media{File: "image-0.png", SourceURL: "https://example.com/0.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = manifest.Add(metadata.Media{File: "image-1.png", SourceURL: "https://example.com/new.png"}); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := metadata.Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Media) != 2 || saved.Media[0].File != "image-0.png" {
+		t.Fatalf("saved media is %+v", saved.Media)
+	}
+	if _, ok := saved.Find("https://example.com/new.png"); !ok {
+		t.Error("expected replaced media to be saved")
+	}
+	if _, ok := saved.Find("https://example.com/1.png"); ok {
+		t.Error("expected media to be replaced")
+	}
+	if err = manifest.Retain([]string{"https://example.com/0.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err = metadata.Read(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Media) != 1 || saved.Media[0].File != "image-0.png" {
+		t.Errorf("retained media is %+v", saved.Media)
+	}
+}
+
+func TestIsUnchanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "image-0.png")
+	if err = ioutil.WriteFile(file, []byte("image"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	media, err := metadata.Describe(file, "https://example.com/0.png", "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !media.IsUnchanged(dir) {
+		t.Error("expected media to be unchanged")
+	}
+	if err = ioutil.WriteFile(file, []byte("changed"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if media.IsUnchanged(dir) {
+		t.Error("expected media to be changed")
+	}
+}
@@ -0,0 +1,105 @@
+package organization
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBaseURL is the base URL of Petstablished.
+const DefaultBaseURL = "https://www.petstablished.com"
+
+// DefaultFile is the file organizations are loaded from when no file is specified.
+const DefaultFile = "organizations.json"
+
+// TwoBlondes is the 2 Blondes All Breed Rescue organization. It is used when no organizations are configured.
+var TwoBlondes = Organization{
+	ID:          "80925",
+	Name:        "2 Blondes All Breed Rescue",
+	BaseURL:     DefaultBaseURL,
+	AdoptionURL: "https://2babrescue.com/adoption-fees-info",
+}
+
+// Sources an organization can list its dogs on.
+const (
+	SourcePetstablished = "petstablished"
+	SourcePetfinder     = "petfinder"
+)
+
+// Organization is a rescue organization that lists its dogs on an adoption platform, Petstablished unless the source
+// says otherwise. The location is the city of the organization (e.g. "Austin, TX") and is used for the hashtags of
+// social posts.
+type Organization struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Source      string `json:"source,omitempty"`
+	BaseURL     string `json:"baseURL"`
+	AdoptionURL string `json:"adoptionURL"`
+	Location    string `json:"location"`
+}
+
+// URL returns the URL of the organization's page.
+func (o Organization) URL() string {
+	baseURL := o.BaseURL
+	if len(baseURL) == 0 {
+		baseURL = DefaultBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/organization/" + o.ID
+}
+
+// String returns the display name of the organization.
+func (o Organization) String() string {
+	if len(o.Name) == 0 {
+		return o.ID
+	}
+	return o.Name
+}
+
+// Load reads the organizations from the specified JSON file. The file contains an array of organizations.
+func Load(file string) ([]Organization, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organizations from %s: %w", file, err)
+	}
+	var orgs []Organization
+	if err = json.Unmarshal(b, &orgs); err != nil {
+		return nil, fmt.Errorf("failed to parse organizations from %s: %w", file, err)
+	}
+	for i, org := range orgs {
+		if len(org.ID) == 0 {
+			return nil, fmt.Errorf("organization %d in %s is missing an id", i, file)
+		}
+	}
+	return orgs, nil
+}
+
+// LoadOrDefault reads the organizations from the specified file. If the file is the default file and it does not
+// exist, the 2 Blondes organization is returned. Any other file that does not exist is an error, so a mistyped file
+// does not look up the wrong organization.
+func LoadOrDefault(file string) ([]Organization, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) && filepath.Clean(file) == DefaultFile {
+		return []Organization{TwoBlondes}, nil
+	}
+	orgs, err := Load(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return []Organization{TwoBlondes}, nil
+	}
+	return orgs, nil
+}
+
+// Find finds the organization with the provided ID or name. The name is matched ignoring case.
+func Find(orgs []Organization, key string) (Organization, bool) {
+	key = strings.TrimSpace(key)
+	for _, org := range orgs {
+		if org.ID == key || strings.EqualFold(org.Name, key) {
+			return org, true
+		}
+	}
+	return Organization{}, false
+}
@@ -0,0 +1,66 @@
+package organization_test
+
+import (
+	"io/ioutil"
+	"os"
+	"pet-spotlight/organization"
+	"testing"
+)
+
+func TestOrganizationURL(t *testing.T) {
+	org := organization.Organization{ID: "123", BaseURL: "http://localhost:8080/"}
+	if org.URL() != "http://localhost:8080/organization/123" {
+		t.Errorf("url is %s", org.URL())
+	}
+	org = organization.Organization{ID: "123"}
+	if org.URL() != "https://www.petstablished.com/organization/123" {
+		t.Errorf("url is %s", org.URL())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "organizations.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	content := `[{"id":"1","name":"Rescue One","adoptionURL":"https://one.org/adopt"},{"id":"2","name":"Rescue Two"}]`
+	if _, err = f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	orgs, err := organization.Load(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orgs) != 2 {
+		t.Fatalf("loaded %d organizations", len(orgs))
+	}
+	org, ok := organization.Find(orgs, "rescue one")
+	if !ok {
+		t.Fatal("failed to find organization by name")
+	}
+	if org.AdoptionURL != "https://one.org/adopt" {
+		t.Errorf("adoption url is %s", org.AdoptionURL)
+	}
+	if _, ok = organization.Find(orgs, "2"); !ok {
+		t.Error("failed to find organization by id")
+	}
+	if _, ok = organization.Find(orgs, "3"); ok {
+		t.Error("found organization that does not exist")
+	}
+}
+
+func TestLoadOrDefault(t *testing.T) {
+	orgs, err := organization.LoadOrDefault(organization.DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orgs) != 1 || orgs[0] != organization.TwoBlondes {
+		t.Errorf("expected default organization, got %+v", orgs)
+	}
+	// A file that is not the default file must exist
+	if _, err = organization.LoadOrDefault("does-not-exist.json"); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
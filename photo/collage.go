@@ -0,0 +1,197 @@
+package photo
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/image/draw"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/io"
+	"strconv"
+	"strings"
+)
+
+// CollageFileName is the name of the collage written to the directory of a dog.
+const CollageFileName = "collage.jpg"
+
+// DefaultCollageFile is the file the collage is loaded from when no file is specified.
+const DefaultCollageFile = "collage.json"
+
+// DefaultCollage is the collage used when no collage is configured.
+var DefaultCollage = Collage{Template: "2x2", Width: 1080, Height: 1080, Padding: 10, Background: "#ffffff", Caption: true}
+
+// captionRatio is the height of the caption relative to the height of the collage.
+const captionRatio = 0.08
+
+// Collage is a grid of the photos of a dog.
+type Collage struct {
+	// Template is the grid as columns x rows, e.g. 2x2 or 3x1.
+	Template string `json:"template"`
+	// Width of the collage in pixels.
+	Width int `json:"width"`
+	// Height of the collage in pixels.
+	Height int `json:"height"`
+	// Padding between the photos and around the edge in pixels.
+	Padding int `json:"padding"`
+	// Background is the hex color behind the photos, e.g. #ffffff.
+	Background string `json:"background"`
+	// Caption adds the name of the dog below the photos.
+	Caption bool `json:"caption"`
+}
+
+// LoadCollage reads the collage from the specified JSON file. Any value missing from the file keeps the value of the
+// default collage.
+func LoadCollage(file string) (Collage, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Collage{}, fmt.Errorf("failed to read collage from %s: %w", file, err)
+	}
+	c := DefaultCollage
+	if err = json.Unmarshal(b, &c); err != nil {
+		return Collage{}, fmt.Errorf("failed to parse collage from %s: %w", file, err)
+	}
+	if _, _, err = c.Grid(); err != nil {
+		return Collage{}, fmt.Errorf("invalid collage in %s: %w", file, err)
+	}
+	if _, err = parseColor(c.Background); err != nil {
+		return Collage{}, fmt.Errorf("invalid collage in %s: %w", file, err)
+	}
+	return c, nil
+}
+
+// LoadCollageOrDefault reads the collage from the specified file. If the file does not exist, the default collage is
+// returned.
+func LoadCollageOrDefault(file string) (Collage, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return DefaultCollage, nil
+	}
+	return LoadCollage(file)
+}
+
+// Grid returns the number of columns and rows of the template.
+func (c Collage) Grid() (int, int, error) {
+	parts := strings.Split(strings.ToLower(c.Template), "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("template %q must be columns x rows", c.Template)
+	}
+	columns, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || columns < 1 {
+		return 0, 0, fmt.Errorf("template %q has invalid columns", c.Template)
+	}
+	rows, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || rows < 1 {
+		return 0, 0, fmt.Errorf("template %q has invalid rows", c.Template)
+	}
+	return columns, rows, nil
+}
+
+// Create lays the photos out in the grid of the template. Photos are cropped around their center to fill their cell.
+// Cells without a photo are left empty. When the collage has a caption, the caption is written below the photos.
+func (c Collage) Create(photos []image.Image, caption string) (*image.RGBA, error) {
+	columns, rows, err := c.Grid()
+	if err != nil {
+		return nil, err
+	}
+	background, err := parseColor(c.Background)
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	gridHeight := c.Height
+	captionHeight := 0
+	if c.Caption && len(caption) > 0 {
+		captionHeight = int(float64(c.Height) * captionRatio)
+		gridHeight -= captionHeight
+	}
+	cellWidth := (c.Width - c.Padding*(columns+1)) / columns
+	cellHeight := (gridHeight - c.Padding*(rows+1)) / rows
+	if cellWidth < 1 || cellHeight < 1 {
+		return nil, fmt.Errorf("padding of %d is too large for a %dx%d collage", c.Padding, c.Width, c.Height)
+	}
+	for i, photo := range photos {
+		if i >= columns*rows {
+			break
+		}
+		x := c.Padding + (i%columns)*(cellWidth+c.Padding)
+		y := c.Padding + (i/columns)*(cellHeight+c.Padding)
+		cell := Fill(photo, cellWidth, cellHeight)
+		draw.Draw(dst, image.Rect(x, y, x+cellWidth, y+cellHeight), cell, image.Point{}, draw.Src)
+	}
+	if captionHeight > 0 {
+		text, err := renderText(caption, float64(captionHeight)*0.7, contrast(background), nil)
+		if err != nil {
+			return nil, err
+		}
+		x := (c.Width - text.Bounds().Dx()) / 2
+		y := gridHeight + (captionHeight-text.Bounds().Dy())/2 - c.Padding/2
+		draw.Draw(dst, image.Rect(x, y, x+text.Bounds().Dx(), y+text.Bounds().Dy()), text, image.Point{}, draw.Over)
+	}
+	return dst, nil
+}
+
+// WriteCollage creates the collage from the first downloaded images in the directory of a dog and writes it next to
+// the description. The file that was written is returned.
+func WriteCollage(directory string, c Collage, name string) (string, error) {
+	columns, rows, err := c.Grid()
+	if err != nil {
+		return "", err
+	}
+	files, err := Images(directory)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no images in %s to create a collage from", directory)
+	}
+	if len(files) > columns*rows {
+		files = files[:columns*rows]
+	}
+	photos := make([]image.Image, len(files))
+	for i, file := range files {
+		if photos[i], err = Decode(file); err != nil {
+			return "", err
+		}
+	}
+	collage, err := c.Create(photos, name)
+	if err != nil {
+		return "", err
+	}
+	b, err := EncodeJPEG(collage, 0)
+	if err != nil {
+		return "", err
+	}
+	collageFile := filepath.Join(directory, CollageFileName)
+	if err = io.WriteFile(string(b), collageFile); err != nil {
+		return "", err
+	}
+	return collageFile, nil
+}
+
+// parseColor parses a hex color such as #ffffff or #fff.
+func parseColor(hex string) (color.RGBA, error) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("color %q must be a hex color such as #ffffff", hex)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("color %q must be a hex color such as #ffffff", hex)
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 255}, nil
+}
+
+// contrast returns black or white, whichever is easier to read on the background.
+func contrast(background color.RGBA) color.Color {
+	luminance := 0.299*float64(background.R) + 0.587*float64(background.G) + 0.114*float64(background.B)
+	if luminance > 128 {
+		return color.Black
+	}
+	return color.White
+}
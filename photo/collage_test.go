@@ -0,0 +1,78 @@
+package photo_test
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/photo"
+	"testing"
+)
+
+func solid(c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCollageGrid(t *testing.T) {
+	columns, rows, err := photo.Collage{Template: "3x1"}.Grid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns != 3 || rows != 1 {
+		t.Errorf("grid is %dx%d", columns, rows)
+	}
+	if _, _, err = (photo.Collage{Template: "three"}).Grid(); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestCollageCreate(t *testing.T) {
+	c := photo.Collage{Template: "2x1", Width: 210, Height: 100, Padding: 10, Background: "#0000ff"}
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	collage, err := c.Create([]image.Image{solid(red), solid(green)}, "Bella")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collage.At(5, 5) != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("padding is %v", collage.At(5, 5))
+	}
+	if collage.At(50, 50) != red {
+		t.Errorf("first cell is %v", collage.At(50, 50))
+	}
+	if collage.At(150, 50) != green {
+		t.Errorf("second cell is %v", collage.At(150, 50))
+	}
+}
+
+func TestWriteCollage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"image-0.png", "image-1.png", "image-10.png"} {
+		writePNG(t, filepath.Join(dir, name), 30, 20)
+	}
+	file, err := photo.WriteCollage(dir, photo.DefaultCollage, "Bella")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != filepath.Join(dir, photo.CollageFileName) {
+		t.Errorf("wrote %s", file)
+	}
+	img, err := photo.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != photo.DefaultCollage.Width || img.Bounds().Dy() != photo.DefaultCollage.Height {
+		t.Errorf("size is %v", img.Bounds())
+	}
+}
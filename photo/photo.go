@@ -0,0 +1,144 @@
+package photo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"pet-spotlight/io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxQuality  = 92
+	minQuality  = 40
+	qualityStep = 8
+)
+
+// ErrOverBudget is returned when an image cannot be encoded within the byte budget of a preset.
+var ErrOverBudget = errors.New("image is over the byte budget")
+
+var imageExtensions = []string{".jpg", ".png", ".gif", ".webp", ".bmp"}
+
+// Images returns the downloaded images in the directory of a dog in the order of the gallery, so image-2 comes before
+// image-10.
+func Images(directory string) ([]string, error) {
+	var images []string
+	for _, extension := range imageExtensions {
+		matches, err := filepath.Glob(filepath.Join(directory, "image-*"+extension))
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, matches...)
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		return imageNumber(images[i]) < imageNumber(images[j])
+	})
+	return images, nil
+}
+
+// imageNumber returns the position of the image in the gallery from its file name.
+func imageNumber(file string) int {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	n, err := strconv.Atoi(strings.TrimPrefix(base, "image-"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// Decode reads the image from the file.
+func Decode(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image %s: %w", file, err)
+	}
+	defer io.CloseResource(f)
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", file, err)
+	}
+	return img, nil
+}
+
+// Fill resizes the image to exactly the width and height. The image is cropped around its center to the aspect ratio
+// of the width and height before it is scaled.
+func Fill(img image.Image, width int, height int) *image.RGBA {
+	bounds := img.Bounds()
+	crop := bounds
+	// Compare the aspect ratios without dividing, wider images are cropped on the sides, taller at the top and bottom
+	if bounds.Dx()*height > bounds.Dy()*width {
+		cropWidth := bounds.Dy() * width / height
+		crop.Min.X = bounds.Min.X + (bounds.Dx()-cropWidth)/2
+		crop.Max.X = crop.Min.X + cropWidth
+	} else {
+		cropHeight := bounds.Dx() * height / width
+		crop.Min.Y = bounds.Min.Y + (bounds.Dy()-cropHeight)/2
+		crop.Max.Y = crop.Min.Y + cropHeight
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG encodes the image as a JPEG that is no larger than the max bytes. The quality is lowered until the image
+// fits. When max bytes is zero, the image is encoded at the highest quality.
+func EncodeJPEG(img image.Image, maxBytes int64) ([]byte, error) {
+	var buffer bytes.Buffer
+	for quality := maxQuality; quality >= minQuality; quality -= qualityStep {
+		buffer.Reset()
+		if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if maxBytes <= 0 || int64(buffer.Len()) <= maxBytes {
+			return buffer.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d bytes at quality %d exceeds %d bytes", ErrOverBudget, buffer.Len(), minQuality, maxBytes)
+}
+
+// WritePresets writes a resized copy of each downloaded image in the directory of a dog for each preset. The copies
+// are written as JPEGs to a folder named after the preset. The files that were written are returned.
+func WritePresets(directory string, presets []Preset) ([]string, error) {
+	if len(presets) == 0 {
+		return nil, nil
+	}
+	images, err := Images(directory)
+	if err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, file := range images {
+		img, err := Decode(file)
+		if err != nil {
+			return written, err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) + ".jpg"
+		for _, preset := range presets {
+			b, err := EncodeJPEG(Fill(img, preset.Width, preset.Height), preset.MaxBytes)
+			if err != nil {
+				return written, fmt.Errorf("failed to create %s of %s: %w", preset.Name, file, err)
+			}
+			presetDirectory := filepath.Join(directory, preset.Name)
+			if err = io.MakeDir(presetDirectory); err != nil {
+				return written, err
+			}
+			presetFile := filepath.Join(presetDirectory, name)
+			if err = io.WriteFile(string(b), presetFile); err != nil {
+				return written, err
+			}
+			written = append(written, presetFile)
+		}
+	}
+	return written, nil
+}
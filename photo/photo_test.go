@@ -0,0 +1,89 @@
+package photo_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/photo"
+	"testing"
+)
+
+func writePNG(t *testing.T, file string, width int, height int) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x * y), A: 255})
+		}
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err = png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFill(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	filled := photo.Fill(img, 100, 100)
+	if filled.Bounds().Dx() != 100 || filled.Bounds().Dy() != 100 {
+		t.Errorf("size is %v", filled.Bounds())
+	}
+}
+
+func TestEncodeJPEG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	b, err := photo.EncodeJPEG(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) == 0 {
+		t.Error("expected content")
+	}
+	if _, err = photo.EncodeJPEG(img, 10); !errors.Is(err, photo.ErrOverBudget) {
+		t.Errorf("expected over budget error, got %v", err)
+	}
+}
+
+func TestWritePresets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writePNG(t, filepath.Join(dir, "image-0.png"), 120, 80)
+	presets := []photo.Preset{{Name: "square", Width: 50, Height: 50}, {Name: "story", Width: 27, Height: 48, MaxBytes: 1 << 20}}
+	written, err := photo.WritePresets(dir, presets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("wrote %v", written)
+	}
+	img, err := photo.Decode(filepath.Join(dir, "story", "image-0.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 27 || img.Bounds().Dy() != 48 {
+		t.Errorf("story size is %v", img.Bounds())
+	}
+}
+
+func TestSelectPresets(t *testing.T) {
+	presets, err := photo.SelectPresets(photo.DefaultPresets, "story, Facebook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) != 2 || presets[0].Name != "story" || presets[1].Name != "facebook" {
+		t.Errorf("selected %+v", presets)
+	}
+	if _, err = photo.SelectPresets(photo.DefaultPresets, "myspace"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
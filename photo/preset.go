@@ -0,0 +1,82 @@
+package photo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DefaultPresetsFile is the file presets are loaded from when no file is specified.
+const DefaultPresetsFile = "presets.json"
+
+// DefaultPresets are the presets used when no presets are configured.
+var DefaultPresets = []Preset{
+	{Name: "instagram-square", Width: 1080, Height: 1080, MaxBytes: 8 << 20},
+	{Name: "instagram-portrait", Width: 1080, Height: 1350, MaxBytes: 8 << 20},
+	{Name: "facebook", Width: 1200, Height: 630, MaxBytes: 4 << 20},
+	{Name: "story", Width: 1080, Height: 1920, MaxBytes: 8 << 20},
+}
+
+// Preset is the size of the images posted to a social media platform. The images are written to a folder named after
+// the preset.
+type Preset struct {
+	Name     string `json:"name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	MaxBytes int64  `json:"maxBytes"`
+}
+
+// LoadPresets reads the presets from the specified JSON file. The file contains an array of presets.
+func LoadPresets(file string) ([]Preset, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets from %s: %w", file, err)
+	}
+	var presets []Preset
+	if err = json.Unmarshal(b, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse presets from %s: %w", file, err)
+	}
+	for i, preset := range presets {
+		if len(preset.Name) == 0 || preset.Width <= 0 || preset.Height <= 0 {
+			return nil, fmt.Errorf("preset %d in %s must have a name, width and height", i, file)
+		}
+	}
+	return presets, nil
+}
+
+// LoadPresetsOrDefault reads the presets from the specified file. If the file does not exist, the default presets
+// are returned.
+func LoadPresetsOrDefault(file string) ([]Preset, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return DefaultPresets, nil
+	}
+	return LoadPresets(file)
+}
+
+// SelectPresets returns the presets with the comma separated names. All the presets are returned when names is "all".
+func SelectPresets(presets []Preset, names string) ([]Preset, error) {
+	if strings.TrimSpace(names) == "all" {
+		return presets, nil
+	}
+	var selected []Preset
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		found := false
+		for _, preset := range presets {
+			if strings.EqualFold(preset.Name, name) {
+				selected = append(selected, preset)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("preset %q is not configured", name)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,271 @@
+package photo
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/goki/freetype/truetype"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"pet-spotlight/io"
+	"strings"
+)
+
+// DefaultWatermarkFile is the file the watermark is loaded from when no file is specified.
+const DefaultWatermarkFile = "watermark.json"
+
+// WatermarkDirectory is the folder in the directory of a dog that the watermarked images are written to.
+const WatermarkDirectory = "watermarked"
+
+// Positions of the watermark on the image.
+const (
+	TopLeft     = "top-left"
+	TopRight    = "top-right"
+	BottomLeft  = "bottom-left"
+	BottomRight = "bottom-right"
+	Center      = "center"
+)
+
+const (
+	defaultOpacity  = 0.6
+	defaultScale    = 0.2
+	defaultTextSize = 0.04
+	// marginRatio is the space between the watermark and the edge of the image relative to the shortest side.
+	marginRatio = 0.02
+)
+
+// Watermark is a logo and/or a line of text that is placed over images to credit the rescue.
+type Watermark struct {
+	// Logo is the path to a PNG of the logo.
+	Logo string `json:"logo"`
+	// Text is a line of text, such as the name of the rescue or its URL.
+	Text string `json:"text"`
+	// Position is where the watermark is placed. Defaults to bottom-right.
+	Position string `json:"position"`
+	// Opacity of the watermark from 0 to 1. Defaults to 0.6.
+	Opacity float64 `json:"opacity"`
+	// Scale is the width of the logo relative to the width of the image. Defaults to 0.2.
+	Scale float64 `json:"scale"`
+	// TextSize is the height of the text relative to the height of the image. Defaults to 0.04.
+	TextSize float64 `json:"textSize"`
+}
+
+// LoadWatermark reads the watermark from the specified JSON file. A relative logo path is relative to the file.
+func LoadWatermark(file string) (Watermark, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Watermark{}, fmt.Errorf("failed to read watermark from %s: %w", file, err)
+	}
+	var w Watermark
+	if err = json.Unmarshal(b, &w); err != nil {
+		return Watermark{}, fmt.Errorf("failed to parse watermark from %s: %w", file, err)
+	}
+	if len(w.Logo) > 0 && !filepath.IsAbs(w.Logo) {
+		w.Logo = filepath.Join(filepath.Dir(file), w.Logo)
+	}
+	if err = w.validate(); err != nil {
+		return Watermark{}, fmt.Errorf("invalid watermark in %s: %w", file, err)
+	}
+	return w, nil
+}
+
+func (w Watermark) validate() error {
+	if len(w.Logo) == 0 && len(w.Text) == 0 {
+		return fmt.Errorf("a logo or text is required")
+	}
+	switch w.Position {
+	case "", TopLeft, TopRight, BottomLeft, BottomRight, Center:
+	default:
+		return fmt.Errorf("unsupported position %q", w.Position)
+	}
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return fmt.Errorf("opacity must be between 0 and 1")
+	}
+	return nil
+}
+
+func (w Watermark) withDefaults() Watermark {
+	if len(w.Position) == 0 {
+		w.Position = BottomRight
+	}
+	if w.Opacity == 0 {
+		w.Opacity = defaultOpacity
+	}
+	if w.Scale <= 0 {
+		w.Scale = defaultScale
+	}
+	if w.TextSize <= 0 {
+		w.TextSize = defaultTextSize
+	}
+	return w
+}
+
+// Apply returns a copy of the image with the logo and text of the watermark placed over it. The logo may be nil when
+// the watermark only has text.
+func (w Watermark) Apply(img image.Image, logo image.Image) (*image.RGBA, error) {
+	w = w.withDefaults()
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	stamp, err := w.stamp(dst.Bounds(), logo)
+	if err != nil {
+		return nil, err
+	}
+	if stamp == nil {
+		return dst, nil
+	}
+	r := place(dst.Bounds(), stamp.Bounds(), w.Position)
+	mask := image.NewUniform(color.Alpha{A: uint8(w.Opacity * 255)})
+	draw.DrawMask(dst, r, stamp, image.Point{}, mask, image.Point{}, draw.Over)
+	return dst, nil
+}
+
+// stamp creates the image of the scaled logo with the text below it. The logo and text are aligned on the side of
+// the position.
+func (w Watermark) stamp(bounds image.Rectangle, logo image.Image) (*image.RGBA, error) {
+	var scaledLogo *image.RGBA
+	if logo != nil {
+		logoBounds := logo.Bounds()
+		width := int(float64(bounds.Dx()) * w.Scale)
+		height := logoBounds.Dy() * width / logoBounds.Dx()
+		if width > 0 && height > 0 {
+			scaledLogo = image.NewRGBA(image.Rect(0, 0, width, height))
+			draw.CatmullRom.Scale(scaledLogo, scaledLogo.Bounds(), logo, logoBounds, draw.Src, nil)
+		}
+	}
+	var text *image.RGBA
+	if len(w.Text) > 0 {
+		var err error
+		if text, err = renderText(w.Text, float64(bounds.Dy())*w.TextSize, color.White, color.RGBA{A: 200}); err != nil {
+			return nil, err
+		}
+	}
+	if scaledLogo == nil && text == nil {
+		return nil, nil
+	}
+	var parts []*image.RGBA
+	width, height := 0, 0
+	for _, part := range []*image.RGBA{scaledLogo, text} {
+		if part == nil {
+			continue
+		}
+		parts = append(parts, part)
+		if part.Bounds().Dx() > width {
+			width = part.Bounds().Dx()
+		}
+		height += part.Bounds().Dy()
+	}
+	stamp := image.NewRGBA(image.Rect(0, 0, width, height))
+	y := 0
+	for _, part := range parts {
+		x := 0
+		switch {
+		case strings.HasSuffix(w.Position, "right"):
+			x = width - part.Bounds().Dx()
+		case w.Position == Center:
+			x = (width - part.Bounds().Dx()) / 2
+		}
+		draw.Draw(stamp, image.Rect(x, y, x+part.Bounds().Dx(), y+part.Bounds().Dy()), part, image.Point{}, draw.Over)
+		y += part.Bounds().Dy()
+	}
+	return stamp, nil
+}
+
+// renderText draws the text in the color. When a shadow color is provided, the text is drawn over a shadow so it can
+// be read on light and dark images.
+func renderText(text string, size float64, textColor color.Color, shadowColor color.Color) (*image.RGBA, error) {
+	f, err := truetype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	face := truetype.NewFace(f, &truetype.Options{Size: size})
+	defer face.Close()
+	metrics := face.Metrics()
+	shadow := 0
+	if shadowColor != nil {
+		shadow = int(size/20) + 1
+	}
+	width := font.MeasureString(face, text).Ceil() + shadow
+	height := (metrics.Ascent + metrics.Descent).Ceil() + shadow
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	drawer := &font.Drawer{Dst: img, Face: face}
+	if shadowColor != nil {
+		drawer.Src = image.NewUniform(shadowColor)
+		drawer.Dot = fixed.Point26_6{X: fixed.I(shadow), Y: metrics.Ascent + fixed.I(shadow)}
+		drawer.DrawString(text)
+	}
+	drawer.Src = image.NewUniform(textColor)
+	drawer.Dot = fixed.Point26_6{Y: metrics.Ascent}
+	drawer.DrawString(text)
+	return img, nil
+}
+
+// place returns where the stamp is drawn on the image for the position.
+func place(bounds image.Rectangle, stamp image.Rectangle, position string) image.Rectangle {
+	shortest := bounds.Dx()
+	if bounds.Dy() < shortest {
+		shortest = bounds.Dy()
+	}
+	margin := int(float64(shortest) * marginRatio)
+	var x, y int
+	switch position {
+	case TopLeft:
+		x, y = margin, margin
+	case TopRight:
+		x, y = bounds.Dx()-stamp.Dx()-margin, margin
+	case BottomLeft:
+		x, y = margin, bounds.Dy()-stamp.Dy()-margin
+	case Center:
+		x, y = (bounds.Dx()-stamp.Dx())/2, (bounds.Dy()-stamp.Dy())/2
+	default:
+		x, y = bounds.Dx()-stamp.Dx()-margin, bounds.Dy()-stamp.Dy()-margin
+	}
+	return image.Rect(x, y, x+stamp.Dx(), y+stamp.Dy())
+}
+
+// WriteWatermarks writes a watermarked copy of each downloaded image in the directory of a dog. The copies are
+// written as JPEGs to the watermarked folder, the original images are left untouched. The files that were written are
+// returned.
+func WriteWatermarks(directory string, w Watermark) ([]string, error) {
+	var logo image.Image
+	if len(w.Logo) > 0 {
+		var err error
+		if logo, err = Decode(w.Logo); err != nil {
+			return nil, err
+		}
+	}
+	images, err := Images(directory)
+	if err != nil {
+		return nil, err
+	}
+	watermarkDirectory := filepath.Join(directory, WatermarkDirectory)
+	var written []string
+	for _, file := range images {
+		img, err := Decode(file)
+		if err != nil {
+			return written, err
+		}
+		watermarked, err := w.Apply(img, logo)
+		if err != nil {
+			return written, err
+		}
+		b, err := EncodeJPEG(watermarked, 0)
+		if err != nil {
+			return written, err
+		}
+		if err = io.MakeDir(watermarkDirectory); err != nil {
+			return written, err
+		}
+		watermarkFile := filepath.Join(watermarkDirectory, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))+".jpg")
+		if err = io.WriteFile(string(b), watermarkFile); err != nil {
+			return written, err
+		}
+		written = append(written, watermarkFile)
+	}
+	return written, nil
+}
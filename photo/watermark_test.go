@@ -0,0 +1,110 @@
+package photo_test
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/photo"
+	"testing"
+)
+
+func TestApplyLogo(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			logo.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	w := photo.Watermark{Logo: "logo.png", Position: photo.TopLeft, Opacity: 1, Scale: 0.5}
+	watermarked, err := w.Apply(img, logo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if watermarked.Bounds() != img.Bounds() {
+		t.Errorf("bounds are %v", watermarked.Bounds())
+	}
+	// The logo is 100 pixels wide in the top left corner after the margin
+	if r, _, _, _ := watermarked.At(50, 50).RGBA(); r == 0 {
+		t.Error("expected the logo in the top left")
+	}
+	if r, _, _, _ := watermarked.At(150, 50).RGBA(); r != 0 {
+		t.Error("expected no logo on the right")
+	}
+	if r, _, _, _ := img.At(50, 50).RGBA(); r != 0 {
+		t.Error("expected the original image to be untouched")
+	}
+}
+
+func TestApplyText(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+	w := photo.Watermark{Text: "2babrescue.com", TextSize: 0.1}
+	watermarked, err := w.Apply(img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := false
+	for x := 200; x < 400 && !changed; x++ {
+		for y := 300; y < 400 && !changed; y++ {
+			if r, _, _, _ := watermarked.At(x, y).RGBA(); r > 0 {
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		t.Error("expected text in the bottom right")
+	}
+}
+
+func TestWriteWatermarks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watermark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writePNG(t, filepath.Join(dir, "image-0.png"), 60, 40)
+	logoFile := filepath.Join(dir, "logo.png")
+	f, err := os.Create(logoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	written, err := photo.WriteWatermarks(dir, photo.Watermark{Logo: logoFile, Text: "Rescue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || written[0] != filepath.Join(dir, photo.WatermarkDirectory, "image-0.jpg") {
+		t.Errorf("wrote %v", written)
+	}
+}
+
+func TestLoadWatermark(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watermark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "watermark.json")
+	if err = ioutil.WriteFile(file, []byte(`{"logo":"logo.png","position":"top-right","opacity":0.5}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	w, err := photo.LoadWatermark(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Logo != filepath.Join(dir, "logo.png") {
+		t.Errorf("logo is %s", w.Logo)
+	}
+	if err = ioutil.WriteFile(file, []byte(`{"text":"Rescue","position":"middle"}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = photo.LoadWatermark(file); err == nil {
+		t.Error("expected error for unsupported position")
+	}
+}
@@ -0,0 +1,153 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CurrentVersion is the newest version of the profile file that is supported.
+const CurrentVersion = 1
+
+// DefaultFile is the file the profile is loaded from when no file is specified.
+const DefaultFile = "profile.json"
+
+// Default is the built-in profile for the Petstablished markup.
+var Default = Profile{
+	Version: CurrentVersion,
+	Selectors: Selectors{
+		Actions:             ".actions",
+		Age:                 ".pet-age",
+		Breed:               ".pet-breed",
+		Button:              ".button",
+		Clients:             "#oc-clients",
+		Error:               ".error",
+		Name:                "h3",
+		PetContainer:        ".pet-container",
+		PetDescription:      ".pet-description-full",
+		PetGallery:          ".thumb-img",
+		PetGalleryAttribute: "data-pet-gallery-url",
+		PetLink:             ".pet-link",
+		Sex:                 ".pet-sex",
+		URLAttribute:        "href",
+		VideoAttribute:      "href",
+		Weight:              ".pet-weight",
+	},
+	Markers: Markers{
+		Adoption:   "Adoption fee includes the following",
+		Foster:     "Foster",
+		ShowLess:   "show less",
+		WidgetPage: "/widget/dogs?page=%d",
+	},
+}
+
+// Profile is the set of CSS selectors and marker strings used to scrape the dogs from the web pages.
+type Profile struct {
+	Version   int       `json:"version"`
+	Selectors Selectors `json:"selectors"`
+	Markers   Markers   `json:"markers"`
+}
+
+// Selectors are the CSS selectors and attributes of the elements that are scraped.
+type Selectors struct {
+	Actions             string `json:"actions"`
+	Age                 string `json:"age"`
+	Breed               string `json:"breed"`
+	Button              string `json:"button"`
+	Clients             string `json:"clients"`
+	Error               string `json:"error"`
+	Name                string `json:"name"`
+	PetContainer        string `json:"petContainer"`
+	PetDescription      string `json:"petDescription"`
+	PetGallery          string `json:"petGallery"`
+	PetGalleryAttribute string `json:"petGalleryAttribute"`
+	PetLink             string `json:"petLink"`
+	Sex                 string `json:"sex"`
+	URLAttribute        string `json:"urlAttribute"`
+	VideoAttribute      string `json:"videoAttribute"`
+	Weight              string `json:"weight"`
+}
+
+// Markers are the strings within the pages used to find the parts of the content.
+type Markers struct {
+	Adoption   string `json:"adoption"`
+	Foster     string `json:"foster"`
+	ShowLess   string `json:"showLess"`
+	WidgetPage string `json:"widgetPage"`
+}
+
+// Load reads the profile from the specified JSON file. Any selector or marker missing from the file keeps the value
+// of the default profile.
+func Load(file string) (Profile, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to read profile from %s: %w", file, err)
+	}
+	p := Default
+	if err = json.Unmarshal(b, &p); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse profile from %s: %w", file, err)
+	}
+	if p.Version < 1 || p.Version > CurrentVersion {
+		return Profile{}, fmt.Errorf("profile %s has version %d, supported versions are 1 to %d", file, p.Version, CurrentVersion)
+	}
+	if err = validateWidgetPage(p.Markers.WidgetPage); err != nil {
+		return Profile{}, fmt.Errorf("profile %s has an invalid widget page: %w", file, err)
+	}
+	return p, nil
+}
+
+// validateWidgetPage checks that the widget page has exactly one verb for the page number, either %d or %s, e.g.
+// "/widget/dogs?page=%d". A literal percent sign is written as "%%".
+func validateWidgetPage(page string) error {
+	verbs := widgetPageVerbs(page)
+	if len(verbs) != 1 {
+		return fmt.Errorf("%q must have exactly one %%d or %%s for the page number, found %d verbs", page, len(verbs))
+	}
+	if verbs[0] != 'd' && verbs[0] != 's' {
+		return fmt.Errorf("%q must have %%d or %%s for the page number, found %%%c", page, verbs[0])
+	}
+	return nil
+}
+
+// widgetPageVerbs returns the verbs of the widget page, without the flags and width of each verb.
+func widgetPageVerbs(page string) []rune {
+	var verbs []rune
+	runes := []rune(page)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			continue
+		}
+		i++
+		// Skip the flags and width of the verb, e.g. "%03d"
+		for i < len(runes) && strings.ContainsRune("+-# 0123456789", runes[i]) {
+			i++
+		}
+		if i == len(runes) {
+			verbs = append(verbs, '!')
+		} else if runes[i] != '%' {
+			verbs = append(verbs, runes[i])
+		}
+	}
+	return verbs
+}
+
+// WidgetPageURL returns the path of the widget page with the page number.
+func (m Markers) WidgetPageURL(page int) string {
+	if verbs := widgetPageVerbs(m.WidgetPage); len(verbs) == 1 && verbs[0] == 's' {
+		return fmt.Sprintf(m.WidgetPage, strconv.Itoa(page))
+	}
+	return fmt.Sprintf(m.WidgetPage, page)
+}
+
+// LoadOrDefault reads the profile from the specified file. If the file is the default file and it does not exist, the
+// default profile is returned. Any other file that does not exist is an error.
+func LoadOrDefault(file string) (Profile, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) && filepath.Clean(file) == DefaultFile {
+		return Default, nil
+	}
+	return Load(file)
+}
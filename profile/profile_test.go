@@ -0,0 +1,79 @@
+package profile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"pet-spotlight/profile"
+	"testing"
+)
+
+func writeProfile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "profile.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestLoad(t *testing.T) {
+	file := writeProfile(t, `{"version":1,"selectors":{"petLink":".dog-link"},"markers":{"foster":"Foster Me"}}`)
+	defer os.Remove(file)
+	p, err := profile.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Selectors.PetLink != ".dog-link" {
+		t.Errorf("pet link selector is %s", p.Selectors.PetLink)
+	}
+	if p.Markers.Foster != "Foster Me" {
+		t.Errorf("foster marker is %s", p.Markers.Foster)
+	}
+	if p.Selectors.PetContainer != profile.Default.Selectors.PetContainer {
+		t.Errorf("pet container selector is %s", p.Selectors.PetContainer)
+	}
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	file := writeProfile(t, `{"version":99}`)
+	defer os.Remove(file)
+	if _, err := profile.Load(file); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+func TestLoadInvalidWidgetPage(t *testing.T) {
+	for _, page := range []string{"/widget/dogs", "/widget/dogs?page=%d&size=%d", "/widget/dogs?page=%q", "/widget/dogs?page=%"} {
+		file := writeProfile(t, `{"version":1,"markers":{"widgetPage":"`+page+`"}}`)
+		if _, err := profile.Load(file); err == nil {
+			t.Errorf("expected error for widget page %s", page)
+		}
+		os.Remove(file)
+	}
+	file := writeProfile(t, `{"version":1,"markers":{"widgetPage":"/widget/dogs?discount=50%%&page=%s"}}`)
+	defer os.Remove(file)
+	p, err := profile.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := p.Markers.WidgetPageURL(2); url != "/widget/dogs?discount=50%&page=2" {
+		t.Errorf("expected /widget/dogs?discount=50%%&page=2, got %s", url)
+	}
+}
+
+func TestLoadOrDefault(t *testing.T) {
+	p, err := profile.LoadOrDefault(profile.DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != profile.Default {
+		t.Errorf("expected default profile, got %+v", p)
+	}
+	// A file that is not the default file must exist
+	if _, err = profile.LoadOrDefault("does-not-exist.json"); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
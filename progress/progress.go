@@ -0,0 +1,246 @@
+package progress
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phase is the step of downloading a dog that an event is about.
+type Phase string
+
+// Phases of downloading a dog, in the order they happen. A dog ends in the complete, failed or canceled phase.
+const (
+	Matching    Phase = "matching"
+	Description Phase = "description"
+	Image       Phase = "image"
+	Video       Phase = "video"
+	Processing  Phase = "processing"
+	Complete    Phase = "complete"
+	Failed      Phase = "failed"
+	Canceled    Phase = "canceled"
+)
+
+// Event is an update on the download of a dog. An event without a dog is about the download as a whole.
+type Event struct {
+	// Dog is the name of the dog the event is about.
+	Dog   string
+	Phase Phase
+	// File is the name of the image or video the event is about.
+	File string
+	// BytesDone is the number of bytes of the file that have been written.
+	BytesDone int64
+	// BytesTotal is the size of the file. It is zero when the size is not known yet.
+	BytesTotal int64
+	// Rate is the number of bytes of the file written per second.
+	Rate float64
+	// ETA is the time left until the file is written. It is zero when the size of the file is not known.
+	ETA time.Duration
+	// Files is the number of images and videos of the dog to download. It is set once the downloads of the dog are
+	// planned.
+	Files int
+	// Finished is set once the file is written or failed.
+	Finished bool
+	// Message is the text of the event to show to the user. Events that only update the bytes have no message.
+	Message string
+}
+
+// String returns the message of the event.
+func (e Event) String() string {
+	return e.Message
+}
+
+// Status is the state of the download of a dog.
+type Status struct {
+	Dog        string
+	Phase      Phase
+	FilesDone  int
+	Files      int
+	BytesDone  int64
+	BytesTotal int64
+	// Fraction is how much of the dog is downloaded, between 0 and 1.
+	Fraction float64
+}
+
+// Transfer is the state of an image or video that is being downloaded.
+type Transfer struct {
+	Dog        string
+	File       string
+	BytesDone  int64
+	BytesTotal int64
+	Rate       float64
+	ETA        time.Duration
+}
+
+// String describes the transfer, e.g. "Bella image-1: 1.5 MB of 3.0 MB (50%) at 512.0 KB/s, 3s left".
+func (t Transfer) String() string {
+	text := fmt.Sprintf("%s %s: %s", t.Dog, t.File, FormatBytes(t.BytesDone))
+	if t.BytesTotal > 0 {
+		text += fmt.Sprintf(" of %s (%.0f%%)", FormatBytes(t.BytesTotal), float64(t.BytesDone)/float64(t.BytesTotal)*100)
+	}
+	if t.Rate > 0 {
+		text += fmt.Sprintf(" at %s/s", FormatBytes(int64(t.Rate)))
+	}
+	if t.ETA > 0 {
+		text += fmt.Sprintf(", %s left", t.ETA.Round(time.Second))
+	}
+	return text
+}
+
+// file is the state of the download of an image or video.
+type file struct {
+	done     int64
+	total    int64
+	rate     float64
+	eta      time.Duration
+	finished bool
+}
+
+// dogStatus is the state of the download of a dog and its files.
+type dogStatus struct {
+	phase  Phase
+	files  int
+	byFile map[string]*file
+}
+
+// Tracker keeps the state of the downloads of the dogs from the events.
+type Tracker struct {
+	m    sync.RWMutex
+	dogs map[string]*dogStatus
+}
+
+// NewTracker creates a tracker without any dogs.
+func NewTracker() *Tracker {
+	return &Tracker{dogs: make(map[string]*dogStatus)}
+}
+
+// Update applies the event to the state of its dog. Events without a dog are ignored.
+func (t *Tracker) Update(e Event) {
+	if len(e.Dog) == 0 {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	d, ok := t.dogs[e.Dog]
+	if !ok {
+		d = &dogStatus{byFile: make(map[string]*file)}
+		t.dogs[e.Dog] = d
+	}
+	if len(e.Phase) > 0 {
+		d.phase = e.Phase
+	}
+	if e.Files > 0 {
+		d.files = e.Files
+	}
+	if len(e.File) > 0 {
+		f, ok := d.byFile[e.File]
+		if !ok {
+			f = &file{}
+			d.byFile[e.File] = f
+		}
+		f.done = e.BytesDone
+		f.total = e.BytesTotal
+		f.rate = e.Rate
+		f.eta = e.ETA
+		f.finished = f.finished || e.Finished
+	}
+}
+
+// Statuses returns the state of each dog, sorted by the name of the dog.
+func (t *Tracker) Statuses() []Status {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	statuses := make([]Status, 0, len(t.dogs))
+	for name, d := range t.dogs {
+		statuses = append(statuses, d.status(name))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Dog < statuses[j].Dog
+	})
+	return statuses
+}
+
+// Transfers returns the images and videos that are being downloaded, sorted by the name of the dog and the file. The
+// files of dogs that have stopped are not included.
+func (t *Tracker) Transfers() []Transfer {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	var transfers []Transfer
+	for name, d := range t.dogs {
+		if d.phase == Complete || d.phase == Failed || d.phase == Canceled {
+			continue
+		}
+		for fileName, f := range d.byFile {
+			if f.finished {
+				continue
+			}
+			transfers = append(transfers, Transfer{Dog: name, File: fileName, BytesDone: f.done, BytesTotal: f.total, Rate: f.rate, ETA: f.eta})
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].Dog != transfers[j].Dog {
+			return transfers[i].Dog < transfers[j].Dog
+		}
+		return transfers[i].File < transfers[j].File
+	})
+	return transfers
+}
+
+// Fraction returns how much of all the dogs is downloaded, between 0 and 1. Each dog counts the same.
+func (t *Tracker) Fraction() float64 {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	if len(t.dogs) == 0 {
+		return 0
+	}
+	var sum float64
+	for name, d := range t.dogs {
+		sum += d.status(name).Fraction
+	}
+	return sum / float64(len(t.dogs))
+}
+
+func (d *dogStatus) status(name string) Status {
+	s := Status{Dog: name, Phase: d.phase, Files: d.files}
+	var partial float64
+	for _, f := range d.byFile {
+		s.BytesDone += f.done
+		s.BytesTotal += f.total
+		if f.finished {
+			s.FilesDone++
+		} else if f.total > 0 {
+			partial += float64(f.done) / float64(f.total)
+		}
+	}
+	switch {
+	case d.phase == Complete || d.phase == Failed || d.phase == Canceled:
+		// A failed or canceled dog has nothing left to download
+		s.Fraction = 1
+	case d.files > 0:
+		s.Fraction = (float64(s.FilesDone) + partial) / float64(d.files)
+	case d.phase == Processing:
+		// There was nothing to download
+		s.Fraction = 1
+	}
+	if s.Fraction > 1 {
+		s.Fraction = 1
+	}
+	return s
+}
+
+// FormatBytes formats the number of bytes with the largest unit that keeps the number at or above 1, e.g. 1.5 MB.
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	units := []string{"KB", "MB", "GB", "TB"}
+	index := -1
+	for value >= unit && index < len(units)-1 {
+		value /= unit
+		index++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[index])
+}
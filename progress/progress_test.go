@@ -0,0 +1,80 @@
+package progress_test
+
+import (
+	"pet-spotlight/progress"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := progress.NewTracker()
+	if fraction := tracker.Fraction(); fraction != 0 {
+		t.Errorf("fraction without dogs is %f", fraction)
+	}
+	tracker.Update(progress.Event{Message: "'bella' matches 2 dogs"})
+	tracker.Update(progress.Event{Dog: "Bella", Phase: progress.Matching})
+	tracker.Update(progress.Event{Dog: "Max", Phase: progress.Matching})
+	tracker.Update(progress.Event{Dog: "Bella", Phase: progress.Image, Files: 4})
+	tracker.Update(progress.Event{Dog: "Bella", Phase: progress.Image, File: "image-0", BytesDone: 100, BytesTotal: 100, Finished: true})
+	tracker.Update(progress.Event{Dog: "Bella", Phase: progress.Image, File: "image-1", BytesDone: 50, BytesTotal: 100})
+	// The size of a video is not known until it is finished
+	tracker.Update(progress.Event{Dog: "Bella", Phase: progress.Video, File: "video-0", BytesDone: 10})
+
+	statuses := tracker.Statuses()
+	if len(statuses) != 2 || statuses[0].Dog != "Bella" || statuses[1].Dog != "Max" {
+		t.Fatalf("statuses are %+v", statuses)
+	}
+	bella := statuses[0]
+	if bella.Phase != progress.Video || bella.FilesDone != 1 || bella.Files != 4 || bella.BytesDone != 160 {
+		t.Errorf("bella is %+v", bella)
+	}
+	if bella.Fraction != 1.5/4 {
+		t.Errorf("fraction of bella is %f", bella.Fraction)
+	}
+	if fraction := tracker.Fraction(); fraction != 1.5/4/2 {
+		t.Errorf("fraction is %f", fraction)
+	}
+	transfers := tracker.Transfers()
+	if len(transfers) != 2 || transfers[0].File != "image-1" || transfers[1].File != "video-0" {
+		t.Fatalf("transfers are %+v", transfers)
+	}
+
+	tracker.Update(progress.Event{Dog: "Max", Phase: progress.Processing, Files: 0})
+	tracker.Update(progress.Event{Dog: "Bella", Phase: progress.Complete})
+	// A canceled dog has nothing left to download
+	tracker.Update(progress.Event{Dog: "Luna", Phase: progress.Matching})
+	tracker.Update(progress.Event{Dog: "Luna", Phase: progress.Canceled})
+	if fraction := tracker.Fraction(); fraction != 1 {
+		t.Errorf("fraction once complete is %f", fraction)
+	}
+	if transfers := tracker.Transfers(); len(transfers) != 0 {
+		t.Errorf("transfers once complete are %+v", transfers)
+	}
+}
+
+func TestTransferString(t *testing.T) {
+	transfer := progress.Transfer{Dog: "Bella", File: "video-0", BytesDone: 1536 * 1024, BytesTotal: 3 * 1024 * 1024, Rate: 512 * 1024, ETA: 3*time.Second + 100*time.Millisecond}
+	if text := transfer.String(); text != "Bella video-0: 1.5 MB of 3.0 MB (50%) at 512.0 KB/s, 3s left" {
+		t.Errorf("transfer is %q", text)
+	}
+	// Without the size only the bytes done are known
+	transfer = progress.Transfer{Dog: "Bella", File: "video-0", BytesDone: 100}
+	if text := transfer.String(); text != "Bella video-0: 100 B" {
+		t.Errorf("transfer is %q", text)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1536:                   "1.5 KB",
+		5 * 1024 * 1024:        "5.0 MB",
+		3 * 1024 * 1024 * 1024: "3.0 GB",
+	}
+	for bytes, expected := range tests {
+		if formatted := progress.FormatBytes(bytes); formatted != expected {
+			t.Errorf("%d is formatted as %s, expected %s", bytes, formatted, expected)
+		}
+	}
+}
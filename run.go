@@ -1,156 +1,332 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/PuerkitoBio/goquery"
-	"github.com/gocolly/colly"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"pet-spotlight/description"
+	"pet-spotlight/dog"
 	"pet-spotlight/http"
 	"pet-spotlight/io"
+	"pet-spotlight/match"
+	"pet-spotlight/metadata"
+	"pet-spotlight/organization"
+	"pet-spotlight/photo"
+	"pet-spotlight/profile"
+	"pet-spotlight/progress"
+	"pet-spotlight/social"
+	"pet-spotlight/source"
 	"pet-spotlight/sync"
 	"pet-spotlight/wait"
 	"sort"
 	"strings"
+	"text/template"
+	"time"
 )
 
-const (
-	actionsClass           = ".actions"
-	adoptionText           = "Adoption fee includes the following"
-	baseURL                = "https://www.petstablished.com"
-	buttonClass            = ".button"
-	clientsId              = "#oc-clients"
-	dogNameContext         = "dogName"
-	errorClass             = ".error"
-	fosterText             = "Foster"
-	header3                = "h3"
-	linkAttribute          = "href"
-	maxPages               = 100
-	petDescriptionClass    = ".pet-description-full"
-	petContainerClass      = ".pet-container"
-	petGalleryClass        = ".thumb-img"
-	petGalleryURLAttribute = "data-pet-gallery-url"
-	petLinkClass           = ".pet-link"
-	showLessText           = "show less"
-	twoBlondesPath         = "/organization/80925"
-	urlLink                = "href"
-	widgetPage             = "/widget/dogs?page=%d"
-)
+// maxDogs is the number of dogs downloaded at the same time.
+const maxDogs = 10
+
+// DownloadOptions are the options of downloading the dogs.
+type DownloadOptions struct {
+	// Refresh only downloads the images and videos that are new or changed since the dog was last downloaded.
+	Refresh bool
+	// ImageFormat is the format images are converted to. Images keep their original format when it is empty.
+	ImageFormat string
+	// Presets are the social media sizes to create copies of the images in once they are downloaded.
+	Presets []photo.Preset
+	// Watermark is placed over copies of the images once they are downloaded. No copies are made when it is nil.
+	Watermark *photo.Watermark
+	// Collage is created from the images once they are downloaded. No collage is created when it is nil.
+	Collage *photo.Collage
+	// Platforms are the social media platforms to compose posts of the dogs for once the images are processed.
+	Platforms []social.Platform
+	// MatchThreshold is the lowest confidence a listing is matched to a dog at. The default threshold is used when it
+	// is zero.
+	MatchThreshold float64
+	// Disambiguate picks the listing when a dog matches more than one listing. When it is nil, nothing is downloaded
+	// when a dog matches more than one listing.
+	Disambiguate Disambiguator
+	// Template writes the description of the dogs. The built-in adoption template is used when it is nil.
+	Template *template.Template
+	// Client downloads the images and videos. The default client is used when it is nil.
+	Client *nethttp.Client
+}
+
+// ErrAmbiguous is returned when a dog matches more than one listing and no listing was picked.
+var ErrAmbiguous = errors.New("dog matches more than one listing")
 
-const defaultDescription = `👇👇SUBMIT AN APPLICATION HERE: 👇👇
-https://2babrescue.com/adoption-fees-info`
+// ErrMissing is returned when some of the dogs that were asked for do not match any listing. The dogs that matched are
+// still downloaded.
+var ErrMissing = errors.New("failed to find dogs")
+
+// Disambiguator picks the listing of the dog that was asked for when the dog matches more than one listing. It
+// returns the index of the candidate to download, or -1 to skip the dog.
+type Disambiguator func(query string, candidates []dog.Dog) (int, error)
+
+// selection is the listing that was picked for a dog that was asked for.
+type selection struct {
+	query      string
+	confidence float64
+	dog        *dog.Dog
+}
 
-// RunDogDownloads starts scrapping the description and the pictures of the specified dogs to the specified directory.
-// First, it must match the specified dog names against all available dogs on the web page. When it finds a match
-// it will grab the description of the dog and visit the dog's personal information page.
-// On the personal page, it will download all images there are of the dog.
-func RunDogDownloads(dogs string, baseDirectory string, progressChannel chan string, errorChannel chan error) error {
+// RunDogDownloads starts scrapping the description and the pictures of the specified dogs from the source to the
+// specified directory.
+// First, it must match the specified dog names against all available dogs of the source. Every listing is
+// collected before anything is downloaded, so a name matching more than one dog is caught. When it finds a match
+// it will write the description of the dog and fetch the dog's personal information.
+// Then, it will download all images and videos there are of the dog. The matched dogs are returned.
+// The progress of each dog is sent to the progress channel as it moves through the phases.
+// When the context is done, no more requests are started and the downloads in progress are stopped. The dogs that
+// were completed are reported to the progress channel and the error of the context is returned. When some of the dogs
+// are not found, ErrMissing is returned along with the matched dogs.
+func RunDogDownloads(ctx context.Context, src source.Source, dogs string, baseDirectory string, opts DownloadOptions, progressChannel chan progress.Event, errorChannel chan error) ([]dog.Dog, error) {
+	defer close(progressChannel)
+	if opts.Template == nil {
+		opts.Template = description.Default()
+	}
 	// Convert the comma sep list of dogs to a map
 	dogMap := createDogMap(dogs)
-	// Create the scrappers
-	availableDogs := colly.NewCollector(colly.Async(true))
-	if err := availableDogs.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: 10}); err != nil {
-		return fmt.Errorf("failed to set parallel limit: %w", err)
+	// Find the listings of the dogs
+	listCtx := http.WithRetryReport(ctx, func(r http.Retry) {
+		progressChannel <- progress.Event{Phase: progress.Matching, Message: r.String()}
+	})
+	listings, err := src.ListDogs(listCtx, errorChannel)
+	if err != nil {
+		return nil, err
 	}
-	dogPictures := availableDogs.Clone()
-
-	// Save the current dog to use when downloading pictures
-	isDone := sync.AtomicBoolean{}
+	selections, err := selectDogs(dogMap, listings, opts, progressChannel)
+	if err != nil {
+		return nil, err
+	}
+	// The dogs that have been matched and the dogs that have been completely downloaded
+	matches := sync.DogList{}
+	completed := sync.DogList{}
+	wg := wait.NewBoundedWaitGroup(maxDogs)
+	for _, s := range selections {
+		if err = wg.AddContext(ctx); err != nil {
+			break
+		}
+		matches.Add(s.dog)
+		go downloadDog(ctx, src, s, baseDirectory, opts, &completed, progressChannel, errorChannel, &wg)
+	}
+	wg.Wait()
+	if err = ctx.Err(); err != nil {
+		progressChannel <- progress.Event{Message: joinCanceled(selections, completed.Get())}
+		return matches.Get(), err
+	}
+	missing := dogMap.GetMissing()
+	progressChannel <- progress.Event{Message: joinMissing(missing)}
+	if len(missing) > 0 {
+		return matches.Get(), fmt.Errorf("%w: %s", ErrMissing, strings.Join(missing, ", "))
+	}
+	return matches.Get(), nil
+}
 
-	// Handle when last page is reached
-	availableDogs.OnHTML(errorClass, func(e *colly.HTMLElement) {
-		isDone.Set(true)
+// downloadDog creates the dir and description.txt file of the dog, then downloads the images and videos of the dog
+// and creates the copies of the images for the options. The dog is added to the completed dogs once everything is
+// done. When the context is done, the downloads are stopped and the images are not processed.
+func downloadDog(ctx context.Context, src source.Source, s selection, baseDirectory string, opts DownloadOptions, completed *sync.DogList, progressChannel chan progress.Event, errorChannel chan error, b *wait.BoundedWaitGroup) {
+	defer b.Done()
+	d := s.dog
+	// The dog failed unless it gets to the end or the download is canceled
+	final := progress.Failed
+	defer func() {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: final}
+	}()
+	// The retries of the requests of the dog are logged against the dog
+	ctx = http.WithRetryReport(ctx, func(r http.Retry) {
+		progressChannel <- progress.Event{Dog: d.Name, Message: fmt.Sprintf("%s: %s", d.Name, r)}
 	})
+	dogName := d.Key()
+	d.Directory = baseDirectory + "/" + d.DirName()
+	if err := io.MakeDir(d.Directory); err != nil {
+		errorChannel <- err
+		return
+	}
+	// Write the description with the link for adopting
+	progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Description}
+	scrapedAt := time.Now().UTC()
+	desc, err := description.Render(opts.Template, description.NewData(*d, scrapedAt))
+	if err != nil {
+		errorChannel <- err
+		return
+	}
+	descFile := d.Directory + "/description.txt"
+	written, err := io.WriteFileIfChanged(desc, descFile)
+	if err != nil {
+		errorChannel <- err
+		return
+	}
+	if !written {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Description, Message: fmt.Sprintf("Description of %s is unchanged", d.Name)}
+	}
+	// Fetch the pictures from the dog's page
+	if err = src.FetchDetail(ctx, d); err != nil {
+		if ctx.Err() != nil {
+			final = progress.Canceled
+		} else {
+			errorChannel <- err
+		}
+		return
+	}
+	media, err := src.ListMedia(ctx, *d)
+	if err != nil {
+		if ctx.Err() != nil {
+			final = progress.Canceled
+		} else {
+			errorChannel <- err
+		}
+		return
+	}
+	// When refreshing, the previous manifest is used to skip the media that has not changed
+	var previous metadata.Metadata
+	if opts.Refresh {
+		if previous, err = metadata.Read(d.Directory); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errorChannel <- err
+		}
+	}
+	unchanged, downloads := planDownloads(d.Directory, media, previous, opts.Refresh)
+	// Record where everything came from. The manifest is saved as each file completes. The records of the previous
+	// manifest are kept until their files are downloaded again, so a refresh that is stopped does not lose the records
+	// of the files still on disk
+	manifest := metadata.NewManifest(d.Directory, metadata.Metadata{
+		Name:         d.Name,
+		Query:        s.query,
+		Confidence:   s.confidence,
+		Organization: d.Organization.String(),
+		URL:          d.URL,
+		ScrapedAt:    scrapedAt,
+		Media:        append([]metadata.Media(nil), previous.Media...),
+	})
+	if err = manifest.Save(); err != nil {
+		errorChannel <- err
+	}
+	if len(unchanged) > 0 {
+		progressChannel <- progress.Event{Dog: d.Name, Message: fmt.Sprintf("Skipping %d unchanged files of %s", len(unchanged), dogName)}
+	}
+	// Save all the images
+	progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Image, Files: len(downloads), Message: fmt.Sprintf("Downloading %s...", dogName)}
+	wg := wait.NewBoundedWaitGroup(5)
+	for _, file := range downloads {
+		if wg.AddContext(ctx) != nil {
+			break
+		}
+		go download(ctx, opts.Client, baseDirectory, *d, file, opts.ImageFormat, manifest, progressChannel, errorChannel, &wg)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		final = progress.Canceled
+		progressChannel <- progress.Event{Dog: d.Name, Message: fmt.Sprintf("Stopped downloading %s", dogName)}
+		return
+	}
+	// The media no longer in the gallery of the dog is no longer recorded
+	if len(previous.Media) > 0 {
+		sourceURLs := make([]string, len(media))
+		for i, m := range media {
+			sourceURLs[i] = m.URL
+		}
+		if err = manifest.Retain(sourceURLs); err != nil {
+			errorChannel <- err
+		}
+	}
+	// Create the watermarked copies of the images
+	if opts.Watermark != nil {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Processing, Message: fmt.Sprintf("Watermarking images of %s...", dogName)}
+		if _, err := photo.WriteWatermarks(d.Directory, *opts.Watermark); err != nil {
+			errorChannel <- err
+		}
+	}
+	// Create the copies of the images for social media
+	if len(opts.Presets) > 0 {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Processing, Message: fmt.Sprintf("Creating social media images of %s...", dogName)}
+		if _, err := photo.WritePresets(d.Directory, opts.Presets); err != nil {
+			errorChannel <- err
+		}
+	}
+	// Create the collage of the images
+	if opts.Collage != nil {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Processing, Message: fmt.Sprintf("Creating collage of %s...", dogName)}
+		if _, err := photo.WriteCollage(d.Directory, *opts.Collage, d.Name); err != nil {
+			errorChannel <- err
+		}
+	}
+	// Compose the social media posts
+	if len(opts.Platforms) > 0 {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Processing, Message: fmt.Sprintf("Composing social media posts of %s...", dogName)}
+		if _, err := social.WritePosts(d.Directory, *d, opts.Platforms); err != nil {
+			errorChannel <- err
+		}
+	}
+	completed.Add(d)
+	final = progress.Complete
+}
 
-	// Handle when the page of all the available dogs is loaded
-	availableDogs.OnHTML(petLinkClass, func(e *colly.HTMLElement) {
-		if dogMap.IsCompete() {
-			if !isDone.Get() {
-				isDone.Set(true)
-			}
-			return
-		}
-		name := e.ChildText(header3)
-		dogName := strings.ReplaceAll(strings.TrimSpace(strings.ToLower(name)), "\"", "")
-		dogMatch := dogMap.IsMatch(dogName)
-		// If a match then create dir and description.txt file
-		if dogMatch {
-			if err := io.MakeDir(baseDirectory + "/" + dogName); err != nil {
-				errorChannel <- err
-				return
+// selectDogs picks the listing of each dog that was asked for. When a dog matches more than one listing, the
+// disambiguator of the options picks the listing. Without a disambiguator, the ambiguous dogs are returned as an error
+// so that nothing is downloaded.
+func selectDogs(dogMap *sync.DogMap, listings []dog.Dog, opts DownloadOptions, progressChannel chan progress.Event) ([]selection, error) {
+	threshold := opts.MatchThreshold
+	if threshold <= 0 {
+		threshold = match.DefaultThreshold
+	}
+	names := make([]string, len(listings))
+	for i, listing := range listings {
+		names[i] = listing.Name
+	}
+	var selections []selection
+	var ambiguous []string
+	selected := make(map[string]bool)
+	for _, query := range dogMap.Queries() {
+		candidates := match.Candidates(query, names, threshold)
+		if len(candidates) == 0 {
+			continue
+		}
+		picked := candidates[0]
+		if len(candidates) > 1 {
+			candidateDogs := make([]dog.Dog, len(candidates))
+			candidateNames := make([]string, len(candidates))
+			for i, candidate := range candidates {
+				candidateDogs[i] = listings[candidate.Index]
+				candidateNames[i] = candidate.Name
 			}
-			progressChannel <- fmt.Sprintf("Found %s", name)
-			fullDescription := e.ChildText(petDescriptionClass)
-			// Remove the adoption fee part
-			index := strings.Index(fullDescription, adoptionText)
-			var desc string
-			if index < 0 {
-				desc = fullDescription[:strings.Index(fullDescription, showLessText)]
-			} else {
-				desc = fullDescription[:index]
+			progressChannel <- progress.Event{Phase: progress.Matching, Message: fmt.Sprintf("'%s' matches %d dogs: %s", query, len(candidates), strings.Join(candidateNames, ", "))}
+			if opts.Disambiguate == nil {
+				ambiguous = append(ambiguous, fmt.Sprintf("'%s' (%s)", query, strings.Join(candidateNames, ", ")))
+				continue
 			}
-			// Add the link for adopting
-			desc += "\n"
-			desc += defaultDescription
-			descFile := baseDirectory + "/" + dogName + "/description.txt"
-			if err := io.WriteFile(desc, descFile); err != nil {
-				errorChannel <- err
-				return
+			index, err := opts.Disambiguate(query, candidateDogs)
+			if err != nil {
+				return nil, err
 			}
-			// Get the link to the dog's page to download pictures
-			link := e.Attr(urlLink)
-			// Add the dog name to the context of the request
-			dogPictures.OnRequest(func(request *colly.Request) {
-				request.Ctx.Put(dogNameContext, dogName)
-			})
-			if err := dogPictures.Visit(link); err != nil {
-				errorChannel <- err
-				return
+			if index < 0 || index >= len(candidates) {
+				progressChannel <- progress.Event{Phase: progress.Matching, Message: fmt.Sprintf("Skipping '%s'", query)}
+				continue
 			}
+			picked = candidates[index]
 		}
-	})
-
-	// When the dog page is loaded, download pictures
-	dogPictures.OnHTML(clientsId, func(e *colly.HTMLElement) {
-		dogName := e.Request.Ctx.Get(dogNameContext)
-		imageURLs := e.ChildAttrs(petGalleryClass, petGalleryURLAttribute)
-		videoURLs := e.ChildAttrs(petGalleryClass, linkAttribute)
-		// Save all the images
-		progressChannel <- fmt.Sprintf("Downloading %s...", dogName)
-		wg := wait.NewBoundedWaitGroup(5)
-		for index, imageURL := range imageURLs {
-			imageFile := fmt.Sprintf("image-%d.png", index)
-			wg.Add(1)
-			go download(baseDirectory, dogName, imageFile, imageURL, errorChannel, &wg)
-		}
-		for index, videoURL := range videoURLs {
-			videoFile := fmt.Sprintf("video-%d.mp4", index)
-			wg.Add(1)
-			go download(baseDirectory, dogName, videoFile, videoURL, errorChannel, &wg)
-		}
-		wg.Wait()
-	})
-
-	// Handle errors
-	availableDogs.OnError(func(r *colly.Response, err error) {
-		errorChannel <- fmt.Errorf("request url: %s, status code %d, error %+v", r.Request.URL, r.StatusCode, err)
-	})
-
-	// Handle errors
-	dogPictures.OnError(func(r *colly.Response, err error) {
-		errorChannel <- fmt.Errorf("request url: %s, status code %d, error %+v", r.Request.URL, r.StatusCode, err)
-	})
-
-	// Start scrapping
-	for i := 1; i < maxPages && !isDone.Get(); i++ {
-		page := fmt.Sprintf(widgetPage, i)
-		if err := availableDogs.Visit(baseURL + twoBlondesPath + page); err != nil {
-			return err
+		dogMap.SetFound(query)
+		d := listings[picked.Index]
+		// Two names can match the same dog, it is only downloaded once
+		if selected[d.URL] {
+			continue
+		}
+		selected[d.URL] = true
+		selections = append(selections, selection{query: query, confidence: picked.Confidence, dog: &d})
+		if picked.Confidence < 1 {
+			progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Matching, Message: fmt.Sprintf("Matched '%s' to '%s' (%.2f)", query, d.Name, picked.Confidence)}
+		} else {
+			progressChannel <- progress.Event{Dog: d.Name, Phase: progress.Matching, Message: fmt.Sprintf("Found %s", d.Name)}
 		}
 	}
-	availableDogs.Wait()
-	dogPictures.Wait()
-	progressChannel <- joinMissing(dogMap.GetMissing())
-	close(progressChannel)
-	return nil
+	if len(ambiguous) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAmbiguous, strings.Join(ambiguous, ", "))
+	}
+	return selections, nil
 }
 
 func createDogMap(dogsList string) *sync.DogMap {
@@ -158,17 +334,101 @@ func createDogMap(dogsList string) *sync.DogMap {
 	return sync.InitializeMap(selectedDogs)
 }
 
-func download(baseDirectory string, dogName string, fileName string, url string, errorChannel chan error, b *wait.BoundedWaitGroup) {
-	defer b.Done()
-	directoryPath := fmt.Sprintf("%s/%s", baseDirectory, dogName)
-	if strings.HasSuffix(fileName, "png") {
-		if err := http.Download(url, directoryPath, fileName); err != nil {
-			errorChannel <- err
+// mediaFile is an image or video to download to the file with the name. The name of an image does not have an
+// extension, it is determined from the content of the image.
+type mediaFile struct {
+	name  string
+	url   string
+	video bool
+}
+
+// planDownloads determines the images and videos of the dog in the directory to download. When refreshing, media from a source URL in
+// the previous metadata is skipped when its file has not changed, and new media is given a file name that is not in
+// use. Otherwise all media is downloaded and named by its position in the gallery.
+func planDownloads(directory string, media []source.Media, previous metadata.Metadata, refresh bool) ([]metadata.Media, []mediaFile) {
+	var unchanged []metadata.Media
+	var downloads []mediaFile
+	used := make(map[string]bool)
+	for _, m := range previous.Media {
+		used[trimExtension(m.File)] = true
+	}
+	plan := func(urls []string, pattern string, video bool) {
+		next := 0
+		for index, url := range urls {
+			if !refresh {
+				downloads = append(downloads, mediaFile{name: fmt.Sprintf(pattern, index), url: url, video: video})
+				continue
+			}
+			if recorded, ok := previous.Find(url); ok {
+				if recorded.IsUnchanged(directory) {
+					unchanged = append(unchanged, recorded)
+				} else {
+					downloads = append(downloads, mediaFile{name: trimExtension(recorded.File), url: url, video: video})
+				}
+				continue
+			}
+			name := fmt.Sprintf(pattern, next)
+			for used[name] {
+				next++
+				name = fmt.Sprintf(pattern, next)
+			}
+			used[name] = true
+			downloads = append(downloads, mediaFile{name: name, url: url, video: video})
+		}
+	}
+	var imageURLs, videoURLs []string
+	for _, m := range media {
+		if m.Video {
+			videoURLs = append(videoURLs, m.URL)
+		} else {
+			imageURLs = append(imageURLs, m.URL)
 		}
+	}
+	plan(imageURLs, "image-%d", false)
+	plan(videoURLs, "video-%d", true)
+	return unchanged, downloads
+}
+
+func trimExtension(fileName string) string {
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
+// download downloads the image or video of the dog and adds it to the manifest. The progress of the file is sent to the
+// progress channel.
+func download(ctx context.Context, client *nethttp.Client, baseDirectory string, d dog.Dog, media mediaFile, imageFormat string, manifest *metadata.Manifest, progressChannel chan progress.Event, errorChannel chan error, b *wait.BoundedWaitGroup) {
+	defer b.Done()
+	directoryPath := fmt.Sprintf("%s/%s", baseDirectory, d.DirName())
+	phase := progress.Image
+	if media.video {
+		phase = progress.Video
+	}
+	progressChannel <- progress.Event{Dog: d.Name, Phase: phase, File: media.name}
+	report := func(p io.Progress) {
+		progressChannel <- progress.Event{Dog: d.Name, Phase: phase, File: media.name, BytesDone: p.Written, BytesTotal: p.Total, Rate: p.Rate, ETA: p.ETA}
+	}
+	var file http.File
+	var err error
+	if media.video {
+		file, err = http.DownloadVideo(ctx, client, media.url, directoryPath, media.name+".mp4", report)
 	} else {
-		if err := http.DownloadVideo(url, directoryPath, fileName); err != nil {
+		file, err = http.Download(ctx, client, media.url, directoryPath, media.name, imageFormat, report)
+	}
+	if err != nil {
+		// A download stopped by the context is not a problem of the download
+		if ctx.Err() == nil {
 			errorChannel <- err
+			progressChannel <- progress.Event{Dog: d.Name, Phase: phase, File: media.name, Finished: true}
 		}
+		return
+	}
+	m, err := metadata.Describe(file.Path, file.SourceURL, file.ContentType)
+	if err != nil {
+		errorChannel <- err
+		return
+	}
+	progressChannel <- progress.Event{Dog: d.Name, Phase: phase, File: media.name, BytesDone: m.Size, BytesTotal: m.Size, Finished: true}
+	if err = manifest.Add(m); err != nil {
+		errorChannel <- err
 	}
 }
 
@@ -180,51 +440,63 @@ func joinMissing(missing []string) string {
 	return "\nFailed to find:\n" + strings.Join(missing, "\n")
 }
 
-// RunGetFosters looks up all the dogs that are foster-able and returns all the dogs in a list.
-func RunGetFosters(errorChannel chan error) ([]string, error) {
-	// Create the scrappers
-	availableDogs := colly.NewCollector(colly.Async(true))
-	if err := availableDogs.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: 10}); err != nil {
-		return nil, fmt.Errorf("failed to set parallel limit: %w", err)
+// joinCanceled reports the dogs that were completely downloaded before the downloads were canceled and the dogs that
+// were not.
+func joinCanceled(selections []selection, completed []dog.Dog) string {
+	done := make(map[string]bool)
+	var completedNames, remainingNames []string
+	for _, d := range completed {
+		done[d.URL] = true
+		completedNames = append(completedNames, d.Name)
 	}
-
-	// List of dogs to be fostered
-	fosters := sync.DogList{}
-	isDone := sync.AtomicBoolean{}
-
-	// Handle when last page is reached
-	availableDogs.OnHTML(errorClass, func(e *colly.HTMLElement) {
-		isDone.Set(true)
-	})
-
-	// Handle when the page of all the available dogs is loaded
-	availableDogs.OnHTML(petContainerClass, func(e *colly.HTMLElement) {
-		var dogName string
-		dom := e.DOM
-		dom.Find(petLinkClass).Each(func(i int, selection *goquery.Selection) {
-			dogName = strings.TrimSpace(selection.Find(header3).Text())
-		})
-		var buttonName string
-		dom.Find(actionsClass).Each(func(i int, selection *goquery.Selection) {
-			buttonName = selection.Find(buttonClass).Text()
-		})
-		if strings.Contains(buttonName, fosterText) {
-			fosters.Add(dogName)
+	for _, s := range selections {
+		if !done[s.dog.URL] {
+			remainingNames = append(remainingNames, s.dog.Name)
 		}
-	})
+	}
+	sort.Strings(completedNames)
+	sort.Strings(remainingNames)
+	report := "\nCanceled"
+	if len(completedNames) > 0 {
+		report += "\nCompleted:\n" + strings.Join(completedNames, "\n")
+	}
+	if len(remainingNames) > 0 {
+		report += "\nNot completed:\n" + strings.Join(remainingNames, "\n")
+	}
+	return report
+}
 
-	// Handle errors
-	availableDogs.OnError(func(r *colly.Response, err error) {
-		errorChannel <- fmt.Errorf("request url: %s, status code %d, error %+v", r.Request.URL, r.StatusCode, err)
-	})
+// RunGetFosters looks up all the dogs of the source that are foster-able and returns all the dogs in a list. The
+// lookup is stopped when the context is done.
+func RunGetFosters(ctx context.Context, src source.Source, errorChannel chan error) ([]dog.Dog, error) {
+	return src.ListFosters(ctx, errorChannel)
+}
 
-	// Start scrapping
-	for i := 1; i < maxPages && !isDone.Get(); i++ {
-		page := fmt.Sprintf(widgetPage, i)
-		if err := availableDogs.Visit(baseURL + twoBlondesPath + page); err != nil {
+// RunGetBoardingList looks up the dogs that are foster-able across all the provided organizations and returns a
+// single list sorted by name. Each dog is tagged with its organization. The pages of the organizations are scraped
+// using the selectors and markers of the profile and requested with the client. Organizations whose source does not
+// tell which dogs need a foster are reported to the error channel and left out. The lookup is stopped when the context
+// is done.
+func RunGetBoardingList(ctx context.Context, orgs []organization.Organization, p profile.Profile, client *nethttp.Client, errorChannel chan error) ([]dog.Dog, error) {
+	var boardingList []dog.Dog
+	for _, org := range orgs {
+		src, err := source.New(org, p, client)
+		if err != nil {
 			return nil, err
 		}
+		fosters, err := RunGetFosters(ctx, src, errorChannel)
+		if errors.Is(err, source.ErrFostersNotSupported) {
+			// The other organizations are still looked up, the list is reported as missing the organization
+			errorChannel <- fmt.Errorf("boarding list is missing %s: %w", org, err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get fosters of %s: %w", org, err)
+		}
+		boardingList = append(boardingList, fosters...)
 	}
-	availableDogs.Wait()
-	return fosters.Get(), nil
+	sort.Slice(boardingList, func(i, j int) bool {
+		return boardingList[i].Name < boardingList[j].Name
+	})
+	return boardingList, nil
 }
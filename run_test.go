@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	"pet-spotlight/metadata"
+	"pet-spotlight/progress"
+	"pet-spotlight/source"
+	"testing"
+)
+
+func TestPlanDownloadsRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan-downloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "image-0.png"), []byte("unchanged"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	unchanged, err := metadata.Describe(filepath.Join(dir, "image-0.png"), "https://example.com/0.png", "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := metadata.Metadata{Media: []metadata.Media{
+		unchanged,
+		{File: "image-1.png", SourceURL: "https://example.com/1.png", SHA256: "missing"},
+	}}
+	media := []source.Media{
+		{URL: "https://example.com/new.png"},
+		{URL: "https://example.com/1.png"},
+		{URL: "https://youtube.com/watch?v=abc", Video: true},
+		{URL: "https://example.com/0.png"},
+	}
+	skipped, downloads := planDownloads(dir, media, previous, true)
+	if len(skipped) != 1 || skipped[0].File != "image-0.png" {
+		t.Errorf("skipped %+v", skipped)
+	}
+	expected := []mediaFile{
+		{name: "image-2", url: "https://example.com/new.png"},
+		{name: "image-1", url: "https://example.com/1.png"},
+		{name: "video-0", url: "https://youtube.com/watch?v=abc", video: true},
+	}
+	if len(downloads) != len(expected) {
+		t.Fatalf("downloads are %+v", downloads)
+	}
+	for i, file := range expected {
+		if downloads[i] != file {
+			t.Errorf("download %d is %+v", i, downloads[i])
+		}
+	}
+}
+
+func TestPlanDownloads(t *testing.T) {
+	media := []source.Media{{URL: "https://example.com/0.png"}, {URL: "https://example.com/1.png"}}
+	previous := metadata.Metadata{Media: []metadata.Media{{File: "image-0.png", SourceURL: "https://example.com/1.png"}}}
+	skipped, downloads := planDownloads("", media, previous, false)
+	if len(skipped) != 0 {
+		t.Errorf("skipped %+v", skipped)
+	}
+	if len(downloads) != 2 || downloads[0].name != "image-0" || downloads[1].name != "image-1" {
+		t.Errorf("downloads are %+v", downloads)
+	}
+}
+
+func TestSelectDogs(t *testing.T) {
+	listings := []dog.Dog{
+		{Name: "Maxine", URL: "https://example.com/maxine"},
+		{Name: "Max", URL: "https://example.com/max"},
+		{Name: "Bella", URL: "https://example.com/bella"},
+	}
+	progressChannel := make(chan progress.Event, 10)
+	_, err := selectDogs(createDogMap("max,bella"), listings, DownloadOptions{}, progressChannel)
+	if !errors.Is(err, ErrAmbiguous) {
+		t.Fatalf("expected ambiguous error, got %v", err)
+	}
+	opts := DownloadOptions{Disambiguate: func(query string, candidates []dog.Dog) (int, error) {
+		for i, candidate := range candidates {
+			if candidate.Name == "Max" {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}}
+	dogMap := createDogMap("max,bello,rex")
+	selections, err := selectDogs(dogMap, listings, opts, progressChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selections) != 2 {
+		t.Fatalf("selected %d dogs", len(selections))
+	}
+	if selections[0].query != "bello" || selections[0].dog.Name != "Bella" || selections[0].confidence >= 1 {
+		t.Errorf("first selection is %+v", selections[0])
+	}
+	if selections[1].query != "max" || selections[1].dog.Name != "Max" {
+		t.Errorf("second selection is %+v", selections[1])
+	}
+	if missing := dogMap.GetMissing(); len(missing) != 1 || missing[0] != "rex" {
+		t.Errorf("missing %v", missing)
+	}
+}
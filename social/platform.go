@@ -0,0 +1,87 @@
+package social
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DefaultPlatformsFile is the file platforms are loaded from when no file is specified.
+const DefaultPlatformsFile = "platforms.json"
+
+// DefaultPlatforms are the platforms used when no platforms are configured.
+var DefaultPlatforms = []Platform{
+	{Name: "twitter", MaxChars: 280, MaxHashtags: 2, MaxPhotos: 4, Links: true},
+	{Name: "instagram", MaxChars: 2200, MaxHashtags: 10, MaxPhotos: 10, Preset: "instagram-square"},
+	{Name: "facebook", MaxChars: 63206, MaxHashtags: 3, MaxPhotos: 10, Links: true},
+}
+
+// Platform is a social media platform the posts are composed for. The preset is the social media size of the photos
+// to post. The original images are posted when the preset is empty or its copies were not created.
+type Platform struct {
+	Name        string `json:"name"`
+	MaxChars    int    `json:"maxChars"`
+	MaxHashtags int    `json:"maxHashtags"`
+	MaxPhotos   int    `json:"maxPhotos"`
+	Links       bool   `json:"links"`
+	Preset      string `json:"preset"`
+}
+
+// LoadPlatforms reads the platforms from the specified JSON file. The file contains an array of platforms.
+func LoadPlatforms(file string) ([]Platform, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read platforms from %s: %w", file, err)
+	}
+	var platforms []Platform
+	if err = json.Unmarshal(b, &platforms); err != nil {
+		return nil, fmt.Errorf("failed to parse platforms from %s: %w", file, err)
+	}
+	for i, platform := range platforms {
+		if len(platform.Name) == 0 || platform.MaxChars <= 0 {
+			return nil, fmt.Errorf("platform %d in %s must have a name and a character limit", i, file)
+		}
+		if platform.MaxHashtags < 0 || platform.MaxPhotos < 0 {
+			return nil, fmt.Errorf("platform %d in %s must not have a negative hashtag or photo limit", i, file)
+		}
+	}
+	return platforms, nil
+}
+
+// LoadPlatformsOrDefault reads the platforms from the specified file. If the file does not exist, the default
+// platforms are returned.
+func LoadPlatformsOrDefault(file string) ([]Platform, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return DefaultPlatforms, nil
+	}
+	return LoadPlatforms(file)
+}
+
+// SelectPlatforms returns the platforms with the comma separated names. All the platforms are returned when names is
+// "all".
+func SelectPlatforms(platforms []Platform, names string) ([]Platform, error) {
+	if strings.TrimSpace(names) == "all" {
+		return platforms, nil
+	}
+	var selected []Platform
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		found := false
+		for _, platform := range platforms {
+			if strings.EqualFold(platform.Name, name) {
+				selected = append(selected, platform)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("platform %q is not configured", name)
+		}
+	}
+	return selected, nil
+}
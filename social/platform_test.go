@@ -0,0 +1,38 @@
+package social_test
+
+import (
+	"io/ioutil"
+	"os"
+	"pet-spotlight/social"
+	"testing"
+)
+
+func TestLoadPlatforms(t *testing.T) {
+	file, err := ioutil.TempFile("", "platforms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	tests := []struct {
+		content string
+		valid   bool
+	}{
+		{`[{"name": "twitter", "maxChars": 280, "maxHashtags": 2, "maxPhotos": 4}]`, true},
+		{`[{"maxChars": 280}]`, false},
+		{`[{"name": "twitter"}]`, false},
+		{`[{"name": "twitter", "maxChars": 280, "maxHashtags": -1}]`, false},
+		{`[{"name": "twitter", "maxChars": 280, "maxPhotos": -1}]`, false},
+	}
+	for _, test := range tests {
+		if err = ioutil.WriteFile(file.Name(), []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		platforms, err := social.LoadPlatforms(file.Name())
+		if test.valid && (err != nil || len(platforms) != 1) {
+			t.Errorf("expected %s to load, got %v", test.content, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("expected error for %s", test.content)
+		}
+	}
+}
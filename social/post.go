@@ -0,0 +1,237 @@
+package social
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	"pet-spotlight/io"
+	"pet-spotlight/photo"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PostsDirectory is the folder in the directory of a dog the posts are written to.
+const PostsDirectory = "posts"
+
+// PostFileName is the name of the text of a post.
+const PostFileName = "post.txt"
+
+// ellipsis is appended to text that had to be cut in the middle of a sentence.
+const ellipsis = "…"
+
+// genericHashtags are added to the hashtags of every dog.
+var genericHashtags = []string{"#AdoptDontShop", "#RescueDog"}
+
+// Post is the text and the photos of a dog for a platform.
+type Post struct {
+	Platform Platform
+	Text     string
+	Photos   []string
+}
+
+// Compose writes the post of the dog for the platform from its description. The description is cut at the end of a
+// sentence so that the description, the link for adopting and the hashtags fit within the limit of the platform.
+func Compose(d dog.Dog, platform Platform, photos []string) Post {
+	hashtags := Hashtags(d)
+	if len(hashtags) > platform.MaxHashtags {
+		hashtags = hashtags[:platform.MaxHashtags]
+	}
+	var footer []string
+	if platform.Links {
+		link := d.Organization.AdoptionURL
+		if len(link) == 0 {
+			link = d.URL
+		}
+		if len(link) > 0 {
+			footer = append(footer, link)
+		}
+	}
+	if len(hashtags) > 0 {
+		footer = append(footer, strings.Join(hashtags, " "))
+	}
+	body := strings.TrimSpace(d.Description)
+	if len(body) == 0 {
+		body = d.Name
+	}
+	budget := platform.MaxChars
+	for _, part := range footer {
+		budget -= utf8.RuneCountInString(part) + 2
+	}
+	parts := append([]string{Truncate(body, budget)}, footer...)
+	if len(parts[0]) == 0 {
+		parts = parts[1:]
+	}
+	text := strings.Join(parts, "\n\n")
+	if utf8.RuneCountInString(text) > platform.MaxChars {
+		text = Truncate(text, platform.MaxChars)
+	}
+	if len(photos) > platform.MaxPhotos {
+		photos = photos[:platform.MaxPhotos]
+	}
+	return Post{Platform: platform, Text: text, Photos: photos}
+}
+
+// Truncate shortens the text to at most max characters. The text is cut after the last sentence that fits. When not
+// even the first sentence fits, the text is cut after the last word that fits.
+func Truncate(text string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	end := 0
+	for i := 0; i < max; i++ {
+		if isSentenceEnd(runes[i]) && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			end = i + 1
+		}
+	}
+	if end > 0 {
+		return strings.TrimSpace(string(runes[:end]))
+	}
+	cut := string(runes[:max-1])
+	if index := strings.LastIndexFunc(cut, unicode.IsSpace); index > 0 {
+		cut = cut[:index]
+	}
+	return strings.TrimSpace(cut) + ellipsis
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?'
+}
+
+// Hashtags returns the hashtags of the dog from its breed and the location of its organization, followed by the
+// generic rescue hashtags.
+func Hashtags(d dog.Dog) []string {
+	var hashtags []string
+	seen := make(map[string]bool)
+	add := func(words string) {
+		hashtag := toHashtag(words)
+		if len(hashtag) > 1 && !seen[strings.ToLower(hashtag)] {
+			seen[strings.ToLower(hashtag)] = true
+			hashtags = append(hashtags, hashtag)
+		}
+	}
+	breeds := strings.FieldsFunc(d.Breed, func(r rune) bool {
+		return r == '/' || r == ',' || r == '&' || r == '+'
+	})
+	for _, breed := range breeds {
+		breed = strings.TrimSpace(breed)
+		for _, suffix := range []string{" mix", " mixed"} {
+			if strings.HasSuffix(strings.ToLower(breed), suffix) {
+				breed = breed[:len(breed)-len(suffix)]
+			}
+		}
+		switch strings.ToLower(breed) {
+		case "mix", "mixed", "mixed breed", "unknown":
+			continue
+		}
+		add(breed)
+	}
+	if city := strings.TrimSpace(strings.Split(d.Organization.Location, ",")[0]); len(city) > 0 {
+		add(city)
+		add(city + " Dogs")
+	}
+	for _, hashtag := range genericHashtags {
+		add(hashtag)
+	}
+	return hashtags
+}
+
+// toHashtag joins the words into a hashtag, capitalizing each word and removing everything but letters and digits.
+func toHashtag(words string) string {
+	var b strings.Builder
+	b.WriteString("#")
+	for _, word := range strings.Fields(words) {
+		first := true
+		for _, r := range word {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				continue
+			}
+			if first {
+				r = unicode.ToUpper(r)
+				first = false
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SelectPhotos returns the photos of the dog in the directory to post to the platform. The copies of the preset of
+// the platform are used when they were created, otherwise the original images are used.
+func SelectPhotos(directory string, platform Platform) ([]string, error) {
+	images, err := photo.Images(directory)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) > platform.MaxPhotos {
+		images = images[:platform.MaxPhotos]
+	}
+	photos := make([]string, len(images))
+	for i, file := range images {
+		photos[i] = file
+		if len(platform.Preset) == 0 {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) + ".jpg"
+		presetFile := filepath.Join(directory, platform.Preset, name)
+		if _, err := os.Stat(presetFile); err == nil {
+			photos[i] = presetFile
+		}
+	}
+	return photos, nil
+}
+
+// WritePosts composes the posts of the dog in the directory for each platform. Each post is written to a folder named
+// after the platform in the posts folder, along with copies of its photos. The post files that were written are
+// returned.
+func WritePosts(directory string, d dog.Dog, platforms []Platform) ([]string, error) {
+	var written []string
+	if err := io.MakeDir(filepath.Join(directory, PostsDirectory)); err != nil {
+		return nil, err
+	}
+	for _, platform := range platforms {
+		photos, err := SelectPhotos(directory, platform)
+		if err != nil {
+			return written, err
+		}
+		post := Compose(d, platform, photos)
+		postDirectory := filepath.Join(directory, PostsDirectory, platform.Name)
+		if err = os.RemoveAll(postDirectory); err != nil {
+			return written, fmt.Errorf("failed to remove previous post %s: %w", postDirectory, err)
+		}
+		if err = io.MakeDir(postDirectory); err != nil {
+			return written, err
+		}
+		postFile := filepath.Join(postDirectory, PostFileName)
+		if err = io.WriteFile(post.Text, postFile); err != nil {
+			return written, err
+		}
+		for i, file := range post.Photos {
+			photoFile := filepath.Join(postDirectory, fmt.Sprintf("photo-%d%s", i+1, filepath.Ext(file)))
+			if err = copyFile(file, photoFile); err != nil {
+				return written, err
+			}
+		}
+		written = append(written, postFile)
+	}
+	return written, nil
+}
+
+func copyFile(source string, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", source, err)
+	}
+	defer io.CloseResource(in)
+	out, err := os.Create(destination)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", destination, err)
+	}
+	defer io.CloseResource(out)
+	return io.CopyToFile(in, out)
+}
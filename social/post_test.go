@@ -0,0 +1,104 @@
+package social_test
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pet-spotlight/dog"
+	"pet-spotlight/organization"
+	"pet-spotlight/social"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var bella = dog.Dog{
+	Name:  "Bella",
+	Breed: "Labrador Retriever / Beagle Mix",
+	Organization: organization.Organization{
+		ID:          "1",
+		AdoptionURL: "https://rescue.org/adopt",
+		Location:    "Austin, TX",
+	},
+	Description: "Bella is a sweet girl. She loves belly rubs! Is she the dog for you? She is house trained.",
+}
+
+func TestTruncate(t *testing.T) {
+	text := "Bella is a sweet girl. She loves belly rubs! Is she the dog for you?"
+	if truncated := social.Truncate(text, 50); truncated != "Bella is a sweet girl. She loves belly rubs!" {
+		t.Errorf("truncated to %q", truncated)
+	}
+	if truncated := social.Truncate(text, 100); truncated != text {
+		t.Errorf("truncated to %q", truncated)
+	}
+	if truncated := social.Truncate(text, 15); truncated != "Bella is a…" {
+		t.Errorf("truncated to %q", truncated)
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	hashtags := strings.Join(social.Hashtags(bella), " ")
+	expected := "#LabradorRetriever #Beagle #Austin #AustinDogs #AdoptDontShop #RescueDog"
+	if hashtags != expected {
+		t.Errorf("hashtags are %s", hashtags)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	platform := social.Platform{Name: "short", MaxChars: 100, MaxHashtags: 2, MaxPhotos: 1, Links: true}
+	post := social.Compose(bella, platform, []string{"image-0.jpg", "image-1.jpg"})
+	expected := "Bella is a sweet girl. She loves belly rubs!\n\nhttps://rescue.org/adopt\n\n#LabradorRetriever #Beagle"
+	if post.Text != expected {
+		t.Errorf("text is %q", post.Text)
+	}
+	if utf8.RuneCountInString(post.Text) > platform.MaxChars {
+		t.Errorf("text is %d characters", utf8.RuneCountInString(post.Text))
+	}
+	if len(post.Photos) != 1 {
+		t.Errorf("post has %d photos", len(post.Photos))
+	}
+	post = social.Compose(bella, social.Platform{Name: "no-links", MaxChars: 1000}, nil)
+	if post.Text != bella.Description {
+		t.Errorf("text is %q", post.Text)
+	}
+}
+
+func TestWritePosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "posts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeImage(t, filepath.Join(dir, "image-0.png"))
+	writeImage(t, filepath.Join(dir, "image-1.png"))
+	if err = os.Mkdir(filepath.Join(dir, "square"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeImage(t, filepath.Join(dir, "square", "image-0.jpg"))
+	platforms := []social.Platform{{Name: "gram", MaxChars: 2200, MaxPhotos: 10, Preset: "square"}}
+	written, err := social.WritePosts(dir, bella, platforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 {
+		t.Fatalf("wrote %d posts", len(written))
+	}
+	for _, name := range []string{social.PostFileName, "photo-1.jpg", "photo-2.png"} {
+		if _, err = os.Stat(filepath.Join(dir, social.PostsDirectory, "gram", name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func writeImage(t *testing.T, file string) {
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+}
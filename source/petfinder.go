@@ -0,0 +1,328 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"pet-spotlight/dog"
+	"pet-spotlight/io"
+	"pet-spotlight/organization"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPetfinderURL is the base URL of the Petfinder API.
+const DefaultPetfinderURL = "https://api.petfinder.com"
+
+// Environment variables of the credentials of the Petfinder API.
+const (
+	PetfinderClientIDEnv     = "PETFINDER_CLIENT_ID"
+	PetfinderClientSecretEnv = "PETFINDER_CLIENT_SECRET"
+)
+
+const (
+	petfinderPageLimit = 100
+	// tokenExpiryMargin is how long before it expires a token is replaced.
+	tokenExpiryMargin = time.Minute
+)
+
+// ErrMissingCredentials is returned when the credentials of the Petfinder API are not configured.
+var ErrMissingCredentials = errors.New("the Petfinder client ID and secret are not configured")
+
+var embedSourcePattern = regexp.MustCompile(`src=["']([^"']+)["']`)
+
+// Petfinder looks up the dogs of an organization with the Petfinder API v2. The API is authorized with the OAuth client
+// credentials flow.
+type Petfinder struct {
+	org          organization.Organization
+	baseURL      string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+	lock         sync.Mutex
+	token        string
+	expiresAt    time.Time
+}
+
+// NewPetfinder creates the source of the organization on Petfinder. The base URL of the organization is the base URL
+// of the API, defaulting to the Petfinder API.
+func NewPetfinder(org organization.Organization, clientID string, clientSecret string, client *http.Client) *Petfinder {
+	baseURL := org.BaseURL
+	if len(baseURL) == 0 {
+		baseURL = DefaultPetfinderURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Petfinder{
+		org:          org,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       client,
+	}
+}
+
+// NewPetfinderFromEnv creates the source of the organization on Petfinder with the credentials from the environment.
+func NewPetfinderFromEnv(org organization.Organization, client *http.Client) (*Petfinder, error) {
+	clientID := os.Getenv(PetfinderClientIDEnv)
+	clientSecret := os.Getenv(PetfinderClientSecretEnv)
+	if len(clientID) == 0 || len(clientSecret) == 0 {
+		return nil, fmt.Errorf("%w, set %s and %s", ErrMissingCredentials, PetfinderClientIDEnv, PetfinderClientSecretEnv)
+	}
+	return NewPetfinder(org, clientID, clientSecret, client), nil
+}
+
+type tokenResponse struct {
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	AccessToken string `json:"access_token"`
+}
+
+type animalsResponse struct {
+	Animals    []animal   `json:"animals"`
+	Pagination pagination `json:"pagination"`
+}
+
+type animalResponse struct {
+	Animal animal `json:"animal"`
+}
+
+type pagination struct {
+	CurrentPage int `json:"current_page"`
+	TotalPages  int `json:"total_pages"`
+}
+
+type animal struct {
+	ID          int     `json:"id"`
+	URL         string  `json:"url"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Status      string  `json:"status"`
+	Age         string  `json:"age"`
+	Gender      string  `json:"gender"`
+	Breeds      breeds  `json:"breeds"`
+	Photos      []photo `json:"photos"`
+	Videos      []video `json:"videos"`
+}
+
+type breeds struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+	Mixed     bool   `json:"mixed"`
+}
+
+type photo struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+	Full   string `json:"full"`
+}
+
+type video struct {
+	Embed string `json:"embed"`
+}
+
+// ListDogs pages through the adoptable dogs of the organization.
+func (s *Petfinder) ListDogs(ctx context.Context, errorChannel chan error) ([]dog.Dog, error) {
+	var dogs []dog.Dog
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("organization", s.org.ID)
+		query.Set("type", "dog")
+		query.Set("limit", fmt.Sprint(petfinderPageLimit))
+		query.Set("page", fmt.Sprint(page))
+		var resp animalsResponse
+		if err := s.get(ctx, "/v2/animals?"+query.Encode(), &resp); err != nil {
+			return nil, err
+		}
+		for _, a := range resp.Animals {
+			dogs = append(dogs, s.toDog(a))
+		}
+		if page >= resp.Pagination.TotalPages {
+			return dogs, nil
+		}
+	}
+}
+
+// ListFosters returns ErrFostersNotSupported, as the Petfinder API does not tell which dogs need a foster.
+func (s *Petfinder) ListFosters(ctx context.Context, errorChannel chan error) ([]dog.Dog, error) {
+	return nil, fmt.Errorf("organization %s on Petfinder: %w", s.org, ErrFostersNotSupported)
+}
+
+// FetchDetail looks up the dog by its ID for its latest photos and videos.
+func (s *Petfinder) FetchDetail(ctx context.Context, d *dog.Dog) error {
+	if len(d.ID) == 0 {
+		return fmt.Errorf("dog %s does not have a Petfinder ID", d.Name)
+	}
+	var resp animalResponse
+	if err := s.get(ctx, "/v2/animals/"+url.PathEscape(d.ID), &resp); err != nil {
+		return err
+	}
+	detail := s.toDog(resp.Animal)
+	d.ImageURLs = detail.ImageURLs
+	d.VideoURLs = detail.VideoURLs
+	d.Status = detail.Status
+	for _, attribute := range []struct{ value, detail *string }{
+		{value: &d.Breed, detail: &detail.Breed},
+		{value: &d.Age, detail: &detail.Age},
+		{value: &d.Sex, detail: &detail.Sex},
+		{value: &d.Description, detail: &detail.Description},
+	} {
+		if len(*attribute.value) == 0 {
+			*attribute.value = *attribute.detail
+		}
+	}
+	return nil
+}
+
+// ListMedia returns the photos of the dog followed by its videos.
+func (s *Petfinder) ListMedia(ctx context.Context, d dog.Dog) ([]Media, error) {
+	media := make([]Media, 0, len(d.ImageURLs)+len(d.VideoURLs))
+	for _, u := range d.ImageURLs {
+		media = append(media, Media{URL: u})
+	}
+	for _, u := range d.VideoURLs {
+		media = append(media, Media{URL: u, Video: true})
+	}
+	return media, nil
+}
+
+// toDog converts the animal of the API to a dog. The largest size of each photo is used and only YouTube videos are
+// kept, as they are the only videos that can be downloaded.
+func (s *Petfinder) toDog(a animal) dog.Dog {
+	d := dog.Dog{
+		ID:           fmt.Sprint(a.ID),
+		Name:         strings.TrimSpace(a.Name),
+		Organization: s.org,
+		URL:          a.URL,
+		Status:       a.Status,
+		Age:          a.Age,
+		Sex:          a.Gender,
+		Description:  strings.TrimSpace(unescapeHTML(a.Description)),
+	}
+	breed := a.Breeds.Primary
+	if len(a.Breeds.Secondary) > 0 {
+		breed += " / " + a.Breeds.Secondary
+	} else if a.Breeds.Mixed && len(breed) > 0 {
+		breed += " Mix"
+	}
+	d.Breed = breed
+	for _, p := range a.Photos {
+		for _, u := range []string{p.Full, p.Large, p.Medium, p.Small} {
+			if len(u) > 0 {
+				d.ImageURLs = append(d.ImageURLs, u)
+				break
+			}
+		}
+	}
+	for _, v := range a.Videos {
+		if u, ok := youTubeURL(v.Embed); ok {
+			d.VideoURLs = append(d.VideoURLs, u)
+		}
+	}
+	return d
+}
+
+// unescapeHTML unescapes the HTML entities of the text. The API escapes some of the text twice, e.g. "&amp;#39;" for
+// an apostrophe, so the text is unescaped until nothing changes.
+func unescapeHTML(text string) string {
+	for i := 0; i < 3; i++ {
+		unescaped := html.UnescapeString(text)
+		if unescaped == text {
+			break
+		}
+		text = unescaped
+	}
+	return text
+}
+
+// youTubeURL returns the watch URL of the YouTube video embedded in the HTML.
+func youTubeURL(embed string) (string, bool) {
+	match := embedSourcePattern.FindStringSubmatch(embed)
+	if match == nil {
+		return "", false
+	}
+	u, err := url.Parse(match[1])
+	if err != nil || !strings.Contains(u.Host, "youtube") {
+		return "", false
+	}
+	id := strings.TrimPrefix(u.Path, "/embed/")
+	if len(id) == 0 || id == u.Path {
+		return "", false
+	}
+	return "https://www.youtube.com/watch?v=" + id, true
+}
+
+// get requests the path of the API and decodes the JSON response. When the token is rejected, a new token is
+// requested once. The request is canceled when the context is done.
+func (s *Petfinder) get(ctx context.Context, path string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := s.accessToken(ctx, attempt > 0)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request to %s: %w", path, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to request %s: %w", path, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			io.CloseResource(resp.Body)
+			continue
+		}
+		defer io.CloseResource(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("request url: %s, status code %d", path, resp.StatusCode)
+		}
+		if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return fmt.Errorf("failed to parse response of %s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// accessToken returns the token of the client credentials. A new token is requested when the current token is about
+// to expire or when a refresh is forced.
+func (s *Petfinder) accessToken(ctx context.Context, refresh bool) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !refresh && len(s.token) > 0 && time.Now().Before(s.expiresAt) {
+		return s.token, nil
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", s.clientID)
+	form.Set("client_secret", s.clientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v2/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Petfinder token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Petfinder token: %w", err)
+	}
+	defer io.CloseResource(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request Petfinder token, status code %d", resp.StatusCode)
+	}
+	var token tokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse Petfinder token: %w", err)
+	}
+	s.token = token.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return s.token, nil
+}
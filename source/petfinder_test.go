@@ -0,0 +1,139 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"pet-spotlight/dog"
+	"pet-spotlight/organization"
+	"pet-spotlight/profile"
+	"pet-spotlight/source"
+	"strings"
+	"testing"
+)
+
+// petfinderAPI is a mock of the Petfinder API v2 serving two pages of dogs of organization TX1.
+type petfinderAPI struct {
+	tokens  int
+	revoked bool
+}
+
+func (api *petfinderAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v2/oauth2/token" {
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("client_id") != "id" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		api.tokens++
+		api.revoked = false
+		fmt.Fprintf(w, `{"token_type":"Bearer","expires_in":3600,"access_token":"token-%d"}`, api.tokens)
+		return
+	}
+	if api.revoked || r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", api.tokens) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/v2/animals" && r.URL.Query().Get("organization") == "TX1":
+		page := r.URL.Query().Get("page")
+		animals := map[string]string{
+			"1": `{"id":1,"name":"Bella","url":"https://www.petfinder.com/dog/bella-1","status":"adoptable","age":"Young","gender":"Female","breeds":{"primary":"Beagle","mixed":true},"photos":[{"small":"https://photos/1-small.jpg","full":"https://photos/1-full.jpg"}],"videos":[{"embed":"<iframe src=\"https://www.youtube.com/embed/abcdefghijk\"></iframe>"}]}`,
+			"2": `{"id":2,"name":"Max","url":"https://www.petfinder.com/dog/max-2","status":"adoptable","breeds":{"primary":"Boxer","secondary":"Pug"},"description":"Max loves his owner&amp;#39;s couch &amp;amp; naps"}`,
+		}[page]
+		fmt.Fprintf(w, `{"animals":[%s],"pagination":{"current_page":%s,"total_pages":2}}`, animals, page)
+	case r.URL.Path == "/v2/animals/1":
+		fmt.Fprint(w, `{"animal":{"id":1,"name":"Bella","status":"adopted","photos":[{"large":"https://photos/1-large.jpg"},{"medium":"https://photos/2-medium.jpg"}]}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPetfinder(t *testing.T) {
+	api := &petfinderAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	org := organization.Organization{ID: "TX1", Source: organization.SourcePetfinder, BaseURL: server.URL}
+	src := source.NewPetfinder(org, "id", "secret", server.Client())
+	dogs, err := src.ListDogs(context.Background(), make(chan error, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dogs) != 2 {
+		t.Fatalf("listed %d dogs", len(dogs))
+	}
+	bella := dogs[0]
+	if bella.ID != "1" || bella.Name != "Bella" || bella.Breed != "Beagle Mix" || bella.Sex != "Female" || bella.Organization.ID != "TX1" {
+		t.Errorf("bella is %+v", bella)
+	}
+	if len(bella.ImageURLs) != 1 || bella.ImageURLs[0] != "https://photos/1-full.jpg" {
+		t.Errorf("images are %v", bella.ImageURLs)
+	}
+	if len(bella.VideoURLs) != 1 || bella.VideoURLs[0] != "https://www.youtube.com/watch?v=abcdefghijk" {
+		t.Errorf("videos are %v", bella.VideoURLs)
+	}
+	if dogs[1].Breed != "Boxer / Pug" {
+		t.Errorf("breed is %s", dogs[1].Breed)
+	}
+	if dogs[1].Description != "Max loves his owner's couch & naps" {
+		t.Errorf("description is %q", dogs[1].Description)
+	}
+	if api.tokens != 1 {
+		t.Errorf("requested %d tokens", api.tokens)
+	}
+	// An expired token is replaced
+	api.revoked = true
+	if err = src.FetchDetail(context.Background(), &bella); err != nil {
+		t.Fatal(err)
+	}
+	if api.tokens != 2 {
+		t.Errorf("requested %d tokens", api.tokens)
+	}
+	if bella.Status != "adopted" || bella.Breed != "Beagle Mix" {
+		t.Errorf("bella is %+v", bella)
+	}
+	media, err := src.ListMedia(context.Background(), bella)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(media) != 2 || media[0].URL != "https://photos/1-large.jpg" || media[1].URL != "https://photos/2-medium.jpg" {
+		t.Errorf("media is %+v", media)
+	}
+	if err = src.FetchDetail(context.Background(), &dog.Dog{ID: "3", Name: "Rex"}); err == nil {
+		t.Error("expected error for missing dog")
+	}
+}
+
+func TestPetfinderFosters(t *testing.T) {
+	server := httptest.NewServer(&petfinderAPI{})
+	defer server.Close()
+	org := organization.Organization{ID: "TX1", Source: organization.SourcePetfinder, BaseURL: server.URL}
+	src := source.NewPetfinder(org, "id", "secret", server.Client())
+	if _, err := src.ListFosters(context.Background(), make(chan error, 10)); !errors.Is(err, source.ErrFostersNotSupported) {
+		t.Errorf("expected fosters not supported error, got %v", err)
+	}
+}
+
+func TestPetfinderBadCredentials(t *testing.T) {
+	server := httptest.NewServer(&petfinderAPI{})
+	defer server.Close()
+	org := organization.Organization{ID: "TX1", BaseURL: server.URL}
+	src := source.NewPetfinder(org, "id", "wrong", server.Client())
+	if _, err := src.ListDogs(context.Background(), make(chan error, 10)); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("expected token error, got %v", err)
+	}
+}
+
+func TestNewPetfinderMissingCredentials(t *testing.T) {
+	clientID := os.Getenv(source.PetfinderClientIDEnv)
+	defer os.Setenv(source.PetfinderClientIDEnv, clientID)
+	if err := os.Setenv(source.PetfinderClientIDEnv, ""); err != nil {
+		t.Fatal(err)
+	}
+	org := organization.Organization{ID: "TX1", Source: organization.SourcePetfinder}
+	if _, err := source.New(org, profile.Default, nil); err == nil {
+		t.Error("expected error for missing credentials")
+	}
+}
@@ -0,0 +1,225 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+	"net/http"
+	"pet-spotlight/dog"
+	"pet-spotlight/organization"
+	"pet-spotlight/profile"
+	"pet-spotlight/sync"
+	"strings"
+	"time"
+)
+
+const (
+	dogContext = "dog"
+	maxPages   = 100
+	// maxFailedPages is how many pages in a row may fail before the listing is given up.
+	maxFailedPages = 3
+)
+
+// Petstablished scrapes the dogs of an organization from the Petstablished widget pages. The elements of the pages
+// are found using the selectors and markers of the profile.
+type Petstablished struct {
+	org     organization.Organization
+	profile profile.Profile
+	client  *http.Client
+}
+
+// NewPetstablished creates the source of the organization on Petstablished. The base URL of the organization is the
+// base URL of Petstablished. The pages are requested with the transport and timeout of the client, when provided. The
+// pages have no timeout without a client.
+func NewPetstablished(org organization.Organization, p profile.Profile, client *http.Client) *Petstablished {
+	return &Petstablished{org: org, profile: p, client: client}
+}
+
+// newCollector creates a collector that requests the pages with the client. The requests are canceled when the
+// context is done.
+func (s *Petstablished) newCollector(ctx context.Context, options ...func(*colly.Collector)) *colly.Collector {
+	c := colly.NewCollector(options...)
+	transport := http.DefaultTransport
+	// The timeout of the client replaces the timeout of the collector, so there is no limit when it is zero
+	var timeout time.Duration
+	if s.client != nil {
+		if s.client.Transport != nil {
+			transport = s.client.Transport
+		}
+		timeout = s.client.Timeout
+	}
+	c.SetRequestTimeout(timeout)
+	c.WithTransport(contextTransport{ctx: ctx, transport: transport})
+	return c
+}
+
+// contextTransport makes the requests of a collector with the context, as the collector does not take a context.
+type contextTransport struct {
+	ctx       context.Context
+	transport http.RoundTripper
+}
+
+// RoundTrip makes the request with the context of the transport.
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.transport.RoundTrip(req.WithContext(t.ctx))
+}
+
+// ListDogs visits the widget pages of the organization one at a time until the last page is reached. A page that fails
+// is reported to the error channel, as the listing is missing its dogs, and the listing goes on with the next page. The
+// listing fails when several pages in a row fail.
+func (s *Petstablished) ListDogs(ctx context.Context, errorChannel chan error) ([]dog.Dog, error) {
+	// Create the scrapper
+	availableDogs := s.newCollector(ctx)
+
+	// List of all the dogs
+	listings := sync.DogList{}
+	isDone := sync.AtomicBoolean{}
+
+	// Handle when last page is reached
+	availableDogs.OnHTML(s.profile.Selectors.Error, func(e *colly.HTMLElement) {
+		isDone.Set(true)
+	})
+
+	// Handle when the page of all the available dogs is loaded
+	availableDogs.OnHTML(s.profile.Selectors.PetContainer, func(e *colly.HTMLElement) {
+		listings.Add(s.parseListing(e))
+	})
+
+	// Start scrapping. A page is only requested once the previous page is loaded, so no page past the last page is
+	// requested
+	failedPages := 0
+	for i := 1; i < maxPages && !isDone.Get(); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page := s.org.URL() + s.profile.Markers.WidgetPageURL(i)
+		err := availableDogs.Visit(page)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil {
+			failedPages = 0
+			continue
+		}
+		err = fmt.Errorf("request url: %s, error %w", page, err)
+		if failedPages++; failedPages >= maxFailedPages {
+			return nil, err
+		}
+		errorChannel <- fmt.Errorf("the dogs of %s are incomplete, skipped page %d: %w", s.org, i, err)
+	}
+	return listings.Get(), nil
+}
+
+// ListFosters lists the dogs of the organization and keeps the dogs marked as needing a foster.
+func (s *Petstablished) ListFosters(ctx context.Context, errorChannel chan error) ([]dog.Dog, error) {
+	listings, err := s.ListDogs(ctx, errorChannel)
+	if err != nil {
+		return nil, err
+	}
+	var fosters []dog.Dog
+	for _, d := range listings {
+		if d.Foster {
+			fosters = append(fosters, d)
+		}
+	}
+	return fosters, nil
+}
+
+// FetchDetail visits the page of the dog for its gallery and the attributes missing from its listing. A page without the
+// gallery is an error.
+func (s *Petstablished) FetchDetail(ctx context.Context, d *dog.Dog) error {
+	dogPage := s.newCollector(ctx)
+	found := sync.AtomicBoolean{}
+	dogPage.OnHTML(s.profile.Selectors.Clients, func(e *colly.HTMLElement) {
+		found.Set(true)
+		s.parseDetail(e, e.Request.Ctx.GetAny(dogContext).(*dog.Dog))
+	})
+	requestContext := colly.NewContext()
+	requestContext.Put(dogContext, d)
+	if err := dogPage.Request("GET", d.URL, nil, requestContext, nil); err != nil {
+		return fmt.Errorf("request url: %s, error %w", d.URL, err)
+	}
+	// Without the gallery the dog would look like it has no images or videos
+	if !found.Get() {
+		return fmt.Errorf("page of %s at %s has no %q element, the scrape profile may be out of date", d.Name, d.URL, s.profile.Selectors.Clients)
+	}
+	return nil
+}
+
+// ListMedia returns the images of the gallery of the dog followed by its videos.
+func (s *Petstablished) ListMedia(ctx context.Context, d dog.Dog) ([]Media, error) {
+	media := make([]Media, 0, len(d.ImageURLs)+len(d.VideoURLs))
+	for _, url := range d.ImageURLs {
+		media = append(media, Media{URL: url})
+	}
+	for _, url := range d.VideoURLs {
+		media = append(media, Media{URL: url, Video: true})
+	}
+	return media, nil
+}
+
+// parseListing creates the dog from its container on the page listing all the available dogs.
+func (s *Petstablished) parseListing(e *colly.HTMLElement) *dog.Dog {
+	p := s.profile
+	d := &dog.Dog{Organization: s.org}
+	dom := e.DOM
+	dom.Find(p.Selectors.PetLink).Each(func(i int, selection *goquery.Selection) {
+		d.Name = strings.TrimSpace(selection.Find(p.Selectors.Name).Text())
+		if link, ok := selection.Attr(p.Selectors.URLAttribute); ok {
+			d.URL = e.Request.AbsoluteURL(link)
+		}
+	})
+	dom.Find(p.Selectors.Actions).Each(func(i int, selection *goquery.Selection) {
+		d.Status = strings.TrimSpace(selection.Find(p.Selectors.Button).Text())
+	})
+	d.Foster = strings.Contains(d.Status, p.Markers.Foster)
+	d.Description = trimDescription(e.ChildText(p.Selectors.PetDescription), p)
+	s.parseAttributes(e, d)
+	return d
+}
+
+// parseDetail fills the dog with the information from its personal page.
+func (s *Petstablished) parseDetail(e *colly.HTMLElement, d *dog.Dog) {
+	d.ImageURLs = absoluteURLs(e, e.ChildAttrs(s.profile.Selectors.PetGallery, s.profile.Selectors.PetGalleryAttribute))
+	d.VideoURLs = absoluteURLs(e, e.ChildAttrs(s.profile.Selectors.PetGallery, s.profile.Selectors.VideoAttribute))
+	s.parseAttributes(e, d)
+}
+
+// absoluteURLs resolves the URLs against the URL of the page.
+func absoluteURLs(e *colly.HTMLElement, urls []string) []string {
+	for i, u := range urls {
+		urls[i] = e.Request.AbsoluteURL(u)
+	}
+	return urls
+}
+
+// parseAttributes fills the attributes of the dog that have not been found yet.
+func (s *Petstablished) parseAttributes(e *colly.HTMLElement, d *dog.Dog) {
+	attributes := []struct {
+		selector string
+		value    *string
+	}{
+		{selector: s.profile.Selectors.Breed, value: &d.Breed},
+		{selector: s.profile.Selectors.Age, value: &d.Age},
+		{selector: s.profile.Selectors.Sex, value: &d.Sex},
+		{selector: s.profile.Selectors.Weight, value: &d.Weight},
+	}
+	for _, attribute := range attributes {
+		if len(*attribute.value) == 0 && len(attribute.selector) > 0 {
+			*attribute.value = strings.TrimSpace(e.ChildText(attribute.selector))
+		}
+	}
+}
+
+// trimDescription removes the adoption fee part of the description. When there is no adoption fee part, everything
+// after "show less" is removed.
+func trimDescription(fullDescription string, p profile.Profile) string {
+	if index := strings.Index(fullDescription, p.Markers.Adoption); index >= 0 {
+		return fullDescription[:index]
+	}
+	if index := strings.Index(fullDescription, p.Markers.ShowLess); index >= 0 {
+		return fullDescription[:index]
+	}
+	return fullDescription
+}
@@ -0,0 +1,208 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"pet-spotlight/dog"
+	"pet-spotlight/organization"
+	"pet-spotlight/profile"
+	"pet-spotlight/source"
+	"pet-spotlight/sync"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const listingPage = `<html><body>
+<div class="pet-container">
+  <a class="pet-link" href="/pets/public/1"><h3>Bella</h3></a>
+  <div class="pet-breed">Beagle</div>
+  <div class="pet-description-full">Bella loves walks. show less</div>
+  <div class="actions"><a class="button">Adopt Me</a></div>
+</div>
+<div class="pet-container">
+  <a class="pet-link" href="/pets/public/2"><h3>Max</h3></a>
+  <div class="pet-description-full">Max loves naps.</div>
+  <div class="actions"><a class="button">Foster Me</a></div>
+</div>
+</body></html>`
+
+const detailPage = `<html><body><div id="oc-clients">
+  <div class="pet-age">2 years</div>
+  <a class="thumb-img" data-pet-gallery-url="/images/bella-0.jpg"></a>
+  <a class="thumb-img" data-pet-gallery-url="/images/bella-1.jpg"></a>
+  <a class="thumb-img" href="https://www.youtube.com/watch?v=abc"></a>
+</div></body></html>`
+
+func newServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/organization/1/widget/dogs" && r.URL.Query().Get("page") == "1":
+			fmt.Fprint(w, listingPage)
+		case r.URL.Path == "/organization/1/widget/dogs":
+			fmt.Fprint(w, `<html><body><div class="error">No more pets</div></body></html>`)
+		case r.URL.Path == "/pets/public/1":
+			fmt.Fprint(w, detailPage)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestPetstablished(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	org := organization.Organization{ID: "1", BaseURL: server.URL}
+	src := source.NewPetstablished(org, profile.Default, server.Client())
+	errorChannel := make(chan error, 100)
+	dogs, err := src.ListDogs(context.Background(), errorChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(dogs, func(i, j int) bool {
+		return dogs[i].Name < dogs[j].Name
+	})
+	if len(dogs) != 2 {
+		t.Fatalf("listed %d dogs", len(dogs))
+	}
+	bella := dogs[0]
+	if bella.Name != "Bella" || bella.URL != server.URL+"/pets/public/1" || bella.Foster {
+		t.Errorf("bella is %+v", bella)
+	}
+	if strings.TrimSpace(bella.Description) != "Bella loves walks." {
+		t.Errorf("description is %q", bella.Description)
+	}
+	if !dogs[1].Foster {
+		t.Error("expected max to need a foster")
+	}
+	if err = src.FetchDetail(context.Background(), &bella); err != nil {
+		t.Fatal(err)
+	}
+	if bella.Breed != "Beagle" || bella.Age != "2 years" {
+		t.Errorf("attributes are %+v", bella)
+	}
+	media, err := src.ListMedia(context.Background(), bella)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []source.Media{
+		{URL: server.URL + "/images/bella-0.jpg"},
+		{URL: server.URL + "/images/bella-1.jpg"},
+		{URL: "https://www.youtube.com/watch?v=abc", Video: true},
+	}
+	if len(media) != len(expected) {
+		t.Fatalf("media is %+v", media)
+	}
+	for i := range expected {
+		if media[i] != expected[i] {
+			t.Errorf("media %d is %+v", i, media[i])
+		}
+	}
+	if err = src.FetchDetail(context.Background(), &dog.Dog{URL: server.URL + "/pets/public/3"}); err == nil {
+		t.Error("expected error for missing dog page")
+	}
+}
+
+func TestPetstablishedFailedPage(t *testing.T) {
+	failing := sync.AtomicBoolean{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		switch {
+		case failing.Get():
+			w.WriteHeader(http.StatusInternalServerError)
+		case page == "1" || page == "3":
+			fmt.Fprint(w, listingPage)
+		case page == "2":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/pets/public/1":
+			fmt.Fprint(w, `<html><body><div class="pet-age">2 years</div></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><div class="error">No more pets</div></body></html>`)
+		}
+	}))
+	defer server.Close()
+	org := organization.Organization{ID: "1", BaseURL: server.URL}
+	src := source.NewPetstablished(org, profile.Default, server.Client())
+	errorChannel := make(chan error, 100)
+	// The page that failed is reported and the pages after it are still listed
+	dogs, err := src.ListDogs(context.Background(), errorChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dogs) != 4 {
+		t.Errorf("listed %d dogs", len(dogs))
+	}
+	if len(errorChannel) != 1 {
+		t.Errorf("reported %d errors", len(errorChannel))
+	}
+	// A page without the gallery is not a dog without images
+	if err = src.FetchDetail(context.Background(), &dog.Dog{Name: "Bella", URL: server.URL + "/pets/public/1"}); err == nil {
+		t.Error("expected error for a page without the gallery")
+	}
+	failing.Set(true)
+	if _, err = src.ListDogs(context.Background(), errorChannel); err == nil {
+		t.Error("expected error when every page fails")
+	}
+}
+
+func TestPetstablishedTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			// The second organization is slower than the 10 seconds of the collector
+			if strings.HasPrefix(r.URL.Path, "/organization/2/") {
+				time.Sleep(11 * time.Second)
+			} else {
+				time.Sleep(200 * time.Millisecond)
+			}
+			fmt.Fprint(w, listingPage)
+			return
+		}
+		fmt.Fprint(w, `<html><body><div class="error">No more pets</div></body></html>`)
+	}))
+	defer server.Close()
+	org := organization.Organization{ID: "1", BaseURL: server.URL}
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+	errorChannel := make(chan error, 100)
+	// The page slower than the timeout of the client is skipped
+	dogs, err := source.NewPetstablished(org, profile.Default, client).ListDogs(context.Background(), errorChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dogs) != 0 || len(errorChannel) != 1 {
+		t.Errorf("listed %d dogs and reported %d errors", len(dogs), len(errorChannel))
+	}
+	if testing.Short() {
+		return
+	}
+	// Without a timeout, the page slower than the 10 seconds of the collector is listed
+	org.ID = "2"
+	client.Timeout = 0
+	errorChannel = make(chan error, 100)
+	dogs, err = source.NewPetstablished(org, profile.Default, client).ListDogs(context.Background(), errorChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dogs) != 2 || len(errorChannel) != 0 {
+		t.Errorf("listed %d dogs and reported %d errors", len(dogs), len(errorChannel))
+	}
+}
+
+func TestPetstablishedCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Cancel while the first page is loading
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	org := organization.Organization{ID: "1", BaseURL: server.URL}
+	src := source.NewPetstablished(org, profile.Default, server.Client())
+	if _, err := src.ListDogs(ctx, make(chan error, 10)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+}
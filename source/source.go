@@ -0,0 +1,50 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"pet-spotlight/dog"
+	"pet-spotlight/organization"
+	"pet-spotlight/profile"
+	"strings"
+)
+
+// Source is an adoption platform that lists the dogs of an organization. The requests of the source are canceled when
+// the context is done.
+type Source interface {
+	// ListDogs returns all the dogs listed by the organization. Problems that do not stop the listing are sent to the
+	// error channel.
+	ListDogs(ctx context.Context, errorChannel chan error) ([]dog.Dog, error)
+	// ListFosters returns the dogs listed by the organization that need a foster. ErrFostersNotSupported is returned
+	// when the source does not tell which dogs need a foster.
+	ListFosters(ctx context.Context, errorChannel chan error) ([]dog.Dog, error)
+	// FetchDetail fills the dog with the information from its own page.
+	FetchDetail(ctx context.Context, d *dog.Dog) error
+	// ListMedia returns the images and videos of the dog in the order they are shown. The detail of the dog must have
+	// been fetched.
+	ListMedia(ctx context.Context, d dog.Dog) ([]Media, error)
+}
+
+// ErrFostersNotSupported is returned when the source does not tell which dogs need a foster.
+var ErrFostersNotSupported = errors.New("looking up fosters is not supported by this source")
+
+// Media is an image or video of a dog.
+type Media struct {
+	URL   string
+	Video bool
+}
+
+// New returns the source the organization lists its dogs on. Petstablished pages are scraped using the selectors and
+// markers of the profile. The requests are made with the client, or the default client when it is nil.
+func New(org organization.Organization, p profile.Profile, client *http.Client) (Source, error) {
+	switch strings.ToLower(org.Source) {
+	case "", organization.SourcePetstablished:
+		return NewPetstablished(org, p, client), nil
+	case organization.SourcePetfinder:
+		return NewPetfinderFromEnv(org, client)
+	default:
+		return nil, fmt.Errorf("organization %s has unknown source %q", org, org.Source)
+	}
+}
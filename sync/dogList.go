@@ -1,23 +1,30 @@
 package sync
 
-import "sync"
+import (
+	"pet-spotlight/dog"
+	"sync"
+)
 
 // DogList is a thread-safe slice of dogs.
 type DogList struct {
 	m    sync.RWMutex
-	dogs []string
+	dogs []*dog.Dog
 }
 
 // Add adds the dog to the list.
-func (l *DogList) Add(name string) {
+func (l *DogList) Add(d *dog.Dog) {
 	l.m.Lock()
-	l.dogs = append(l.dogs, name)
+	l.dogs = append(l.dogs, d)
 	l.m.Unlock()
 }
 
-// Get retrieves all the dogs.
-func (l *DogList) Get() []string {
+// Get retrieves a copy of all the dogs.
+func (l *DogList) Get() []dog.Dog {
 	l.m.RLock()
-	l.m.RUnlock()
-	return l.dogs
+	defer l.m.RUnlock()
+	dogs := make([]dog.Dog, len(l.dogs))
+	for i, d := range l.dogs {
+		dogs[i] = *d
+	}
+	return dogs
 }
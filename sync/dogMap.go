@@ -1,61 +1,70 @@
 package sync
 
 import (
+	"sort"
 	"strings"
 	"sync"
 )
 
 // DogMap is a thread-safe map of dogs.
 type DogMap struct {
-	m *sync.Map
+	lock sync.Mutex
+	m    map[string]bool
 }
 
 // InitializeMap creates the map with the provided slice of dogs.
 func InitializeMap(dogs []string) *DogMap {
-	var m sync.Map
+	m := make(map[string]bool)
 	for _, dog := range dogs {
-		m.Store(strings.TrimSpace(strings.ToLower(dog)), false)
+		if dog = strings.TrimSpace(strings.ToLower(dog)); len(dog) > 0 {
+			m[dog] = false
+		}
 	}
-	return &DogMap{m: &m}
+	return &DogMap{m: m}
 }
 
-// IsMatch determines if the provided name matches an entry in the map. The provided names must be contained an in a key.
-func (m *DogMap) IsMatch(name string) bool {
-	dogMatch := false
-	m.m.Range(func(dog, alreadyDownloaded interface{}) bool {
-		if !alreadyDownloaded.(bool) {
-			if strings.Contains(name, dog.(string)) {
-				dogMatch = true
-				m.m.Store(dog, true)
-				return false
-			}
-		}
-		return true
-	})
-	return dogMatch
+// Queries returns the sorted entries of the map.
+func (m *DogMap) Queries() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	queries := make([]string, 0, len(m.m))
+	for dog := range m.m {
+		queries = append(queries, dog)
+	}
+	sort.Strings(queries)
+	return queries
+}
+
+// SetFound marks the entry as found.
+func (m *DogMap) SetFound(dog string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.m[dog]; ok {
+		m.m[dog] = true
+	}
 }
 
 // IsCompete determines if all the entries in the map are true.
 func (m *DogMap) IsCompete() bool {
-	isComplete := true
-	m.m.Range(func(_, found interface{}) bool {
-		if !found.(bool) {
-			isComplete = false
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, found := range m.m {
+		if !found {
 			return false
 		}
-		return true
-	})
-	return isComplete
+	}
+	return true
 }
 
 // GetMissing returns all entries with a value of false.
 func (m *DogMap) GetMissing() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	var missing []string
-	m.m.Range(func(name, found interface{}) bool {
-		if !found.(bool) {
-			missing = append(missing, name.(string))
+	for name, found := range m.m {
+		if !found {
+			missing = append(missing, name)
 		}
-		return true
-	})
+	}
 	return missing
 }
@@ -1,6 +1,9 @@
 package wait
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 // BoundedWaitGroup is a wait group that is bounded. Limiting the number of threads.
 type BoundedWaitGroup struct {
@@ -24,6 +27,22 @@ func (bwg *BoundedWaitGroup) Add(delta int) {
 	bwg.wg.Add(delta)
 }
 
+// AddContext adds a single request to the wait group once there is room for it. The context's error is returned when
+// the context is done before there is room, in which case the request is not added.
+func (bwg *BoundedWaitGroup) AddContext(ctx context.Context) error {
+	select {
+	case bwg.ch <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		<-bwg.ch
+		return err
+	}
+	bwg.wg.Add(1)
+	return nil
+}
+
 // Done completes the request.
 func (bwg *BoundedWaitGroup) Done() {
 	bwg.Add(-1)
@@ -0,0 +1,27 @@
+package wait_test
+
+import (
+	"context"
+	"pet-spotlight/wait"
+	"testing"
+	"time"
+)
+
+func TestAddContext(t *testing.T) {
+	wg := wait.NewBoundedWaitGroup(1)
+	if err := wg.AddContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// There is no room until the first request is done
+	if err := wg.AddContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	wg.Done()
+	if err := wg.AddContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Done()
+	wg.Wait()
+}